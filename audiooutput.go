@@ -0,0 +1,254 @@
+package azuretexttospeech
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AudioOutput types represent the supported audio encoding formats for the text-to-speech endpoint.
+// This type is required when requesting to azuretexttospeech.Synthesize text-to-speed request.
+// Each incorporates a bitrate and encoding type. The Speech service supports 48 kHz, 24 kHz, 16 kHz, and 8 kHz audio outputs.
+// See: https://docs.microsoft.com/en-us/azure/cognitive-services/speech-service/rest-text-to-speech#audio-outputs
+type AudioOutput int
+
+const (
+	AudioRIFF8Bit8kHzMonoPCM      AudioOutput = iota // riff-8khz-8bit-mono-mulaw
+	AudioRIFF16Bit16kHzMonoPCM                       // riff-16khz-16bit-mono-pcm
+	AudioRIFF16khz16kbpsMonoSiren                    // riff-16khz-16kbps-mono-siren
+	AudioRIFF24khz16bitMonoPcm                       // riff-24khz-16bit-mono-pcm
+	AudioRAW8Bit8kHzMonoMulaw                        // raw-8khz-8bit-mono-mulaw
+	AudioRAW16Bit16kHzMonoMulaw                      // raw-16khz-16bit-mono-pcm
+	AudioRAW24khz16bitMonoPcm                        // raw-24khz-16bit-mono-pcm
+	AudioSsml16khz16bitMonoTts                       // ssml-16khz-16bit-mono-tts
+	Audio16khz16kbpsMonoSiren                        // audio-16khz-16kbps-mono-siren
+	Audio16khz32kbitrateMonoMp3                      // audio-16khz-32kbitrate-mono-mp3
+	Audio6khz64kbitrateMonoMp3                       // audio-16khz-64kbitrate-mono-mp3
+	Audio16khz128kbitrateMonoMp3                     // audio-16khz-128kbitrate-mono-mp3
+	Audio24khz48kbitrateMonoMp3                      // audio-24khz-48kbitrate-mono-mp3
+	Audio24khz96kbitrateMonoMp3                      // audio-24khz-96kbitrate-mono-mp3
+	// Formats added after the original table are appended to keep the numeric values of the above stable.
+	AudioRIFF8khz8bitMonoALaw         // riff-8khz-8bit-mono-alaw
+	AudioRIFF8khz16bitMonoPcm         // riff-8khz-16bit-mono-pcm
+	AudioRIFF22050hz16bitMonoPcm      // riff-22050hz-16bit-mono-pcm
+	AudioRIFF44100hz16bitMonoPcm      // riff-44100hz-16bit-mono-pcm
+	AudioRIFF48khz16bitMonoPcm        // riff-48khz-16bit-mono-pcm
+	AudioRAW8khz8bitMonoALaw          // raw-8khz-8bit-mono-alaw
+	AudioRAW8khz16bitMonoPcm          // raw-8khz-16bit-mono-pcm
+	AudioRAW22050hz16bitMonoPcm       // raw-22050hz-16bit-mono-pcm
+	AudioRAW44100hz16bitMonoPcm       // raw-44100hz-16bit-mono-pcm
+	AudioRAW48khz16bitMonoPcm         // raw-48khz-16bit-mono-pcm
+	AudioRAW16khz16bitMonoTrueSilk    // raw-16khz-16bit-mono-truesilk
+	AudioRAW24khz16bitMonoTrueSilk    // raw-24khz-16bit-mono-truesilk
+	Audio24khz160kbitrateMonoMp3      // audio-24khz-160kbitrate-mono-mp3
+	Audio48khz96kbitrateMonoMp3       // audio-48khz-96kbitrate-mono-mp3
+	Audio48khz192kbitrateMonoMp3      // audio-48khz-192kbitrate-mono-mp3
+	AudioOgg16khz16bitMonoOpus        // ogg-16khz-16bit-mono-opus
+	AudioOgg24khz16bitMonoOpus        // ogg-24khz-16bit-mono-opus
+	AudioOgg48khz16bitMonoOpus        // ogg-48khz-16bit-mono-opus
+	AudioWebm16khz16bitMonoOpus       // webm-16khz-16bit-mono-opus
+	AudioWebm24khz16bitMonoOpus       // webm-24khz-16bit-mono-opus
+	AudioWebm24khz16bit24kbpsMonoOpus // webm-24khz-16bit-24kbps-mono-opus
+	AudioAmrWb16000hz                 // amr-wb-16000hz
+	AudioG722Mono16khz64kbps          // g722-16khz-64kbps
+)
+
+// AudioContainer is the file or stream format wrapping the encoded audio.
+type AudioContainer string
+
+const (
+	ContainerRIFF AudioContainer = "riff" // RIFF/WAVE file with a header.
+	ContainerRaw  AudioContainer = "raw"  // headerless stream of samples or codec frames.
+	ContainerMP3  AudioContainer = "mp3"  // sequence of MPEG audio frames.
+	ContainerOgg  AudioContainer = "ogg"
+	ContainerWebM AudioContainer = "webm"
+	ContainerAMR  AudioContainer = "amr" // AMR-WB storage format, starting with the "#!AMR-WB\n" magic.
+	ContainerSSML AudioContainer = "ssml"
+)
+
+// AudioCodec is the encoding of the audio samples.
+type AudioCodec string
+
+const (
+	CodecPCM   AudioCodec = "pcm" // signed little-endian linear PCM.
+	CodecMuLaw AudioCodec = "mulaw"
+	CodecALaw  AudioCodec = "alaw"
+	CodecMP3   AudioCodec = "mp3"
+	CodecOpus  AudioCodec = "opus"
+	CodecSiren AudioCodec = "siren"
+	CodecSILK  AudioCodec = "silk"
+	CodecAMRWB AudioCodec = "amr-wb"
+	CodecG722  AudioCodec = "g722"
+	CodecTTS   AudioCodec = "tts"
+)
+
+// AudioFormat describes the audio produced for an AudioOutput.
+type AudioFormat struct {
+	Container  AudioContainer
+	Codec      AudioCodec
+	SampleRate int    // samples per second, per channel.
+	BitDepth   int    // bits per sample for PCM and G.711 codecs; 0 for compressed codecs.
+	Channels   int    // number of interleaved channels.
+	Bitrate    int    // bits per second of the encoded stream; 0 when the encoder uses a variable bitrate.
+	MIMEType   string // value suitable for a Content-Type header.
+	Extension  string // file name extension, including the leading dot.
+}
+
+// audioOutputInfo holds the wire name and metadata for an AudioOutput.
+type audioOutputInfo struct {
+	name   string
+	format AudioFormat
+}
+
+func riffFormat(codec AudioCodec, rate, depth int) AudioFormat {
+	return AudioFormat{ContainerRIFF, codec, rate, depth, 1, rate * depth, "audio/wav", ".wav"}
+}
+
+func rawFormat(codec AudioCodec, rate, depth int) AudioFormat {
+	switch codec {
+	case CodecMuLaw:
+		return AudioFormat{ContainerRaw, codec, rate, depth, 1, rate * depth, "audio/PCMU", ".ulaw"}
+	case CodecALaw:
+		return AudioFormat{ContainerRaw, codec, rate, depth, 1, rate * depth, "audio/PCMA", ".alaw"}
+	}
+	return AudioFormat{ContainerRaw, codec, rate, depth, 1, rate * depth, "audio/L16", ".pcm"}
+}
+
+func mp3Format(rate, kbps int) AudioFormat {
+	return AudioFormat{ContainerMP3, CodecMP3, rate, 0, 1, kbps * 1000, "audio/mpeg", ".mp3"}
+}
+
+func opusFormat(container AudioContainer, rate, kbps int) AudioFormat {
+	return AudioFormat{container, CodecOpus, rate, 0, 1, kbps * 1000, "audio/" + string(container), "." + string(container)}
+}
+
+// audioOutputs is indexed by AudioOutput.
+var audioOutputs = [...]audioOutputInfo{
+	AudioRIFF8Bit8kHzMonoPCM:          {"riff-8khz-8bit-mono-mulaw", riffFormat(CodecMuLaw, 8000, 8)},
+	AudioRIFF16Bit16kHzMonoPCM:        {"riff-16khz-16bit-mono-pcm", riffFormat(CodecPCM, 16000, 16)},
+	AudioRIFF16khz16kbpsMonoSiren:     {"riff-16khz-16kbps-mono-siren", AudioFormat{ContainerRIFF, CodecSiren, 16000, 0, 1, 16000, "audio/wav", ".wav"}},
+	AudioRIFF24khz16bitMonoPcm:        {"riff-24khz-16bit-mono-pcm", riffFormat(CodecPCM, 24000, 16)},
+	AudioRAW8Bit8kHzMonoMulaw:         {"raw-8khz-8bit-mono-mulaw", rawFormat(CodecMuLaw, 8000, 8)},
+	AudioRAW16Bit16kHzMonoMulaw:       {"raw-16khz-16bit-mono-pcm", rawFormat(CodecPCM, 16000, 16)},
+	AudioRAW24khz16bitMonoPcm:         {"raw-24khz-16bit-mono-pcm", rawFormat(CodecPCM, 24000, 16)},
+	AudioSsml16khz16bitMonoTts:        {"ssml-16khz-16bit-mono-tts", AudioFormat{ContainerSSML, CodecTTS, 16000, 16, 1, 0, "application/ssml+xml", ".ssml"}},
+	Audio16khz16kbpsMonoSiren:         {"audio-16khz-16kbps-mono-siren", AudioFormat{ContainerRaw, CodecSiren, 16000, 0, 1, 16000, "audio/siren", ".siren"}},
+	Audio16khz32kbitrateMonoMp3:       {"audio-16khz-32kbitrate-mono-mp3", mp3Format(16000, 32)},
+	Audio6khz64kbitrateMonoMp3:        {"audio-16khz-64kbitrate-mono-mp3", mp3Format(16000, 64)},
+	Audio16khz128kbitrateMonoMp3:      {"audio-16khz-128kbitrate-mono-mp3", mp3Format(16000, 128)},
+	Audio24khz48kbitrateMonoMp3:       {"audio-24khz-48kbitrate-mono-mp3", mp3Format(24000, 48)},
+	Audio24khz96kbitrateMonoMp3:       {"audio-24khz-96kbitrate-mono-mp3", mp3Format(24000, 96)},
+	AudioRIFF8khz8bitMonoALaw:         {"riff-8khz-8bit-mono-alaw", riffFormat(CodecALaw, 8000, 8)},
+	AudioRIFF8khz16bitMonoPcm:         {"riff-8khz-16bit-mono-pcm", riffFormat(CodecPCM, 8000, 16)},
+	AudioRIFF22050hz16bitMonoPcm:      {"riff-22050hz-16bit-mono-pcm", riffFormat(CodecPCM, 22050, 16)},
+	AudioRIFF44100hz16bitMonoPcm:      {"riff-44100hz-16bit-mono-pcm", riffFormat(CodecPCM, 44100, 16)},
+	AudioRIFF48khz16bitMonoPcm:        {"riff-48khz-16bit-mono-pcm", riffFormat(CodecPCM, 48000, 16)},
+	AudioRAW8khz8bitMonoALaw:          {"raw-8khz-8bit-mono-alaw", rawFormat(CodecALaw, 8000, 8)},
+	AudioRAW8khz16bitMonoPcm:          {"raw-8khz-16bit-mono-pcm", rawFormat(CodecPCM, 8000, 16)},
+	AudioRAW22050hz16bitMonoPcm:       {"raw-22050hz-16bit-mono-pcm", rawFormat(CodecPCM, 22050, 16)},
+	AudioRAW44100hz16bitMonoPcm:       {"raw-44100hz-16bit-mono-pcm", rawFormat(CodecPCM, 44100, 16)},
+	AudioRAW48khz16bitMonoPcm:         {"raw-48khz-16bit-mono-pcm", rawFormat(CodecPCM, 48000, 16)},
+	AudioRAW16khz16bitMonoTrueSilk:    {"raw-16khz-16bit-mono-truesilk", AudioFormat{ContainerRaw, CodecSILK, 16000, 0, 1, 0, "audio/SILK", ".silk"}},
+	AudioRAW24khz16bitMonoTrueSilk:    {"raw-24khz-16bit-mono-truesilk", AudioFormat{ContainerRaw, CodecSILK, 24000, 0, 1, 0, "audio/SILK", ".silk"}},
+	Audio24khz160kbitrateMonoMp3:      {"audio-24khz-160kbitrate-mono-mp3", mp3Format(24000, 160)},
+	Audio48khz96kbitrateMonoMp3:       {"audio-48khz-96kbitrate-mono-mp3", mp3Format(48000, 96)},
+	Audio48khz192kbitrateMonoMp3:      {"audio-48khz-192kbitrate-mono-mp3", mp3Format(48000, 192)},
+	AudioOgg16khz16bitMonoOpus:        {"ogg-16khz-16bit-mono-opus", opusFormat(ContainerOgg, 16000, 0)},
+	AudioOgg24khz16bitMonoOpus:        {"ogg-24khz-16bit-mono-opus", opusFormat(ContainerOgg, 24000, 0)},
+	AudioOgg48khz16bitMonoOpus:        {"ogg-48khz-16bit-mono-opus", opusFormat(ContainerOgg, 48000, 0)},
+	AudioWebm16khz16bitMonoOpus:       {"webm-16khz-16bit-mono-opus", opusFormat(ContainerWebM, 16000, 0)},
+	AudioWebm24khz16bitMonoOpus:       {"webm-24khz-16bit-mono-opus", opusFormat(ContainerWebM, 24000, 0)},
+	AudioWebm24khz16bit24kbpsMonoOpus: {"webm-24khz-16bit-24kbps-mono-opus", opusFormat(ContainerWebM, 24000, 24)},
+	AudioAmrWb16000hz:                 {"amr-wb-16000hz", AudioFormat{ContainerAMR, CodecAMRWB, 16000, 0, 1, 0, "audio/amr-wb", ".awb"}},
+	AudioG722Mono16khz64kbps:          {"g722-16khz-64kbps", AudioFormat{ContainerRaw, CodecG722, 16000, 0, 1, 64000, "audio/G722", ".g722"}},
+}
+
+// IsValid returns true if the AudioOutput is listed in the format table.
+func (a AudioOutput) IsValid() bool {
+	return a >= 0 && int(a) < len(audioOutputs)
+}
+
+// String returns the value sent within the X-Microsoft-OutputFormat header.
+func (a AudioOutput) String() string {
+	if !a.IsValid() {
+		return fmt.Sprintf("AudioOutput(%d)", a)
+	}
+	return audioOutputs[a].name
+}
+
+// Format returns the metadata describing the audio produced for the AudioOutput. The zero AudioFormat is returned
+// for invalid values.
+func (a AudioOutput) Format() AudioFormat {
+	if !a.IsValid() {
+		return AudioFormat{}
+	}
+	return audioOutputs[a].format
+}
+
+// AudioOutputs returns all known audio formats in declaration order.
+func AudioOutputs() []AudioOutput {
+	a := make([]AudioOutput, len(audioOutputs))
+	for i := range audioOutputs {
+		a[i] = AudioOutput(i)
+	}
+	return a
+}
+
+// ParseAudioOutput returns the AudioOutput matching the case-insensitive format name `s`, e.g. "riff-24khz-16bit-mono-pcm".
+func ParseAudioOutput(s string) (AudioOutput, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for i, a := range audioOutputs {
+		if a.name == name {
+			return AudioOutput(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%s does not belong to AudioOutput values", s)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface for AudioOutput
+func (a AudioOutput) MarshalText() ([]byte, error) {
+	if !a.IsValid() {
+		return nil, fmt.Errorf("unable to marshal invalid %s", a)
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface for AudioOutput
+func (a *AudioOutput) UnmarshalText(text []byte) error {
+	var err error
+	*a, err = ParseAudioOutput(string(text))
+	return err
+}
+
+// MarshalJSON implements the json.Marshaler interface for AudioOutput
+func (a AudioOutput) MarshalJSON() ([]byte, error) {
+	text, err := a.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for AudioOutput. Numeric values, as written by earlier
+// releases of this package, are accepted as well.
+func (a *AudioOutput) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '"' {
+		var i int
+		if err := json.Unmarshal(data, &i); err != nil {
+			return fmt.Errorf("AudioOutput should be a string, got %s", data)
+		}
+		if !AudioOutput(i).IsValid() {
+			return fmt.Errorf("%d does not belong to AudioOutput values", i)
+		}
+		*a = AudioOutput(i)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("AudioOutput should be a string, got %s", data)
+	}
+	return a.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,78 @@
+package azuretexttospeech
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudioOutputString(t *testing.T) {
+	assert.Equal(t, "riff-24khz-16bit-mono-pcm", AudioRIFF24khz16bitMonoPcm.String())
+	assert.Equal(t, "ogg-48khz-16bit-mono-opus", AudioOgg48khz16bitMonoOpus.String())
+	assert.Equal(t, "AudioOutput(-1)", AudioOutput(-1).String())
+	assert.Equal(t, "AudioOutput(500)", AudioOutput(500).String())
+
+	// numeric values of the original table must remain stable.
+	assert.Equal(t, AudioOutput(13), Audio24khz96kbitrateMonoMp3)
+}
+
+func TestParseAudioOutput(t *testing.T) {
+	for _, a := range AudioOutputs() {
+		p, err := ParseAudioOutput(a.String())
+		assert.NoError(t, err)
+		assert.Equal(t, a, p)
+	}
+
+	a, err := ParseAudioOutput(" RAW-8KHZ-8BIT-MONO-ALAW ")
+	assert.NoError(t, err)
+	assert.Equal(t, AudioRAW8khz8bitMonoALaw, a)
+
+	_, err = ParseAudioOutput("flac-96khz-24bit-stereo")
+	assert.Error(t, err)
+}
+
+func TestAudioOutputFormat(t *testing.T) {
+	for _, a := range AudioOutputs() {
+		f := a.Format()
+		assert.NotEmpty(t, f.Container, a.String())
+		assert.NotEmpty(t, f.MIMEType, a.String())
+		assert.Equal(t, 1, f.Channels, a.String())
+		assert.NotZero(t, f.SampleRate, a.String())
+	}
+
+	f := AudioRIFF48khz16bitMonoPcm.Format()
+	assert.Equal(t, AudioFormat{ContainerRIFF, CodecPCM, 48000, 16, 1, 768000, "audio/wav", ".wav"}, f)
+
+	f = Audio16khz32kbitrateMonoMp3.Format()
+	assert.Equal(t, CodecMP3, f.Codec)
+	assert.Equal(t, 32000, f.Bitrate)
+	assert.Equal(t, ".mp3", f.Extension)
+
+	assert.Equal(t, CodecMuLaw, AudioRIFF8Bit8kHzMonoPCM.Format().Codec)
+	assert.Equal(t, "audio/webm", AudioWebm24khz16bitMonoOpus.Format().MIMEType)
+	assert.Equal(t, AudioFormat{}, AudioOutput(-1).Format())
+}
+
+func TestAudioOutputJSON(t *testing.T) {
+	type job struct {
+		Format AudioOutput `json:"format"`
+	}
+
+	b, err := json.Marshal(job{AudioRAW8khz8bitMonoALaw})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"format":"raw-8khz-8bit-mono-alaw"}`, string(b))
+
+	var j job
+	assert.NoError(t, json.Unmarshal([]byte(`{"format":"audio-48khz-192kbitrate-mono-mp3"}`), &j))
+	assert.Equal(t, Audio48khz192kbitrateMonoMp3, j.Format)
+
+	// legacy numeric encoding.
+	assert.NoError(t, json.Unmarshal([]byte(`{"format":3}`), &j))
+	assert.Equal(t, AudioRIFF24khz16bitMonoPcm, j.Format)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"format":"mp3"}`), &j))
+	assert.Error(t, json.Unmarshal([]byte(`{"format":99}`), &j))
+	_, err = json.Marshal(job{AudioOutput(99)})
+	assert.Error(t, err)
+}
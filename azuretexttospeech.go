@@ -7,13 +7,20 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
 	"time"
 )
 
-// The following are V1 endpoints for Cognitiveservices endpoints
+// The following are V1 endpoints for Cognitiveservices endpoints within the Azure public cloud. See `cloudAPIs`
+// for the sovereign cloud equivalents.
 const textToSpeechAPI = "https://%s.tts.speech.microsoft.com/cognitiveservices/v1"
 const tokenRefreshAPI = "https://%s.api.cognitive.microsoft.com/sts/v1.0/issueToken"
 
+// customVoiceAPI is the endpoint serving Custom Neural Voice deployments.
+// See: https://docs.microsoft.com/en-us/azure/cognitive-services/speech-service/how-to-deploy-and-use-endpoint#use-your-custom-voice
+const customVoiceAPI = "https://%s.voice.speech.microsoft.com/cognitiveservices/v1"
+
 // synthesizeActionTimeout is the amount of time the http client will wait for a response during Synthesize request
 const synthesizeActionTimeout = time.Second * 30
 
@@ -28,14 +35,126 @@ const ttsApiXMLPayload = "<speak version='1.0' xml:lang='%s'><voice xml:lang='%s
 // text in which a user wishes to Synthesize, `region` is the language/locale, `gender` is the desired output voice
 // and `audioOutput` captures the audio format.
 func (az *AzureCSTextToSpeech) SynthesizeWithContext(ctx context.Context, speechText string, locale Locale, gender Gender, audioOutput AudioOutput) ([]byte, error) {
+	result, err := az.SynthesizeResultWithContext(ctx, speechText, locale, gender, audioOutput)
+	if err != nil {
+		return nil, err
+	}
+	return result.Audio, nil
+}
+
+// SynthesizeResultWithContext behaves as SynthesizeWithContext, but returns the audio along with the metadata of the request.
+func (az *AzureCSTextToSpeech) SynthesizeResultWithContext(ctx context.Context, speechText string, locale Locale, gender Gender, audioOutput AudioOutput) (*SynthesisResult, error) {
 
 	description, ok := az.RegionVoiceMap[supportedVoices{gender, locale}]
 	if !ok {
 		return nil, fmt.Errorf("unable to to locate RegionVoiceMap{region=%s, gender=%s} pair", locale, gender)
 	}
 
-	v := voiceXML(speechText, description, locale, gender)
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, az.textToSpeechURL, bytes.NewBufferString(v))
+	v := voice{name: description, locale: locale, gender: gender, endpoint: az.textToSpeechURL}
+	return az.synthesize(ctx, v, speechText, voiceXML(speechText, v.name, v.locale, v.gender), audioOutput)
+}
+
+// synthesize renders the SSML `payload`, speaking `speechText`, with voice `v`. The audio is served from the Cache when
+// possible, the upstream request is shared with identical concurrent calls when Coalesce is set, is refused while the
+// Breaker is open or when it would exceed the budget of Usage, and waits for a slot of the Scheduler when set.
+func (az *AzureCSTextToSpeech) synthesize(ctx context.Context, v voice, speechText, payload string, audioOutput AudioOutput) (*SynthesisResult, error) {
+	if !audioOutput.IsValid() {
+		return nil, fmt.Errorf("unsupported audio output, %s", audioOutput)
+	}
+
+	key := CacheKey(payload, v.name, audioOutput, v.endpoint)
+	if az.Cache != nil {
+		if b, ok := az.Cache.Get(key); ok {
+			result := &SynthesisResult{
+				Audio:       b,
+				AudioOutput: audioOutput,
+				Voice:       v.name,
+				Text:        speechText,
+				Cached:      true,
+				Duration:    audioDuration(b, audioOutput),
+			}
+			return az.postProcess(result)
+		}
+	}
+
+	// the priority is taken from the context of the caller, coalesced requests run detached from it.
+	priority := priorityFrom(ctx)
+	fetch := func(ctx context.Context) (*SynthesisResult, error) {
+		// requests beyond the budget and requests while the breaker is open fail fast, before queueing on the scheduler.
+		reserved := billableSSML(payload, speechText)
+		if az.Usage != nil {
+			if err := az.Usage.reserve(reserved); err != nil {
+				return nil, err
+			}
+		}
+		if az.Breaker != nil {
+			if err := az.Breaker.enter(); err != nil {
+				if az.Usage != nil {
+					az.Usage.cancel(reserved)
+				}
+				return nil, err
+			}
+		}
+		if az.Scheduler != nil {
+			release, err := az.Scheduler.acquire(ctx, priority)
+			if err != nil {
+				if az.Breaker != nil {
+					az.Breaker.abort()
+				}
+				if az.Usage != nil {
+					az.Usage.cancel(reserved)
+				}
+				return nil, err
+			}
+			defer release()
+		}
+		result, err := az.post(ctx, v, speechText, payload, audioOutput)
+		if az.Breaker != nil {
+			az.Breaker.record(err)
+		}
+		if az.Usage != nil {
+			if err == nil {
+				az.Usage.commit(reserved, v, result.BilledCharacters)
+			} else {
+				az.Usage.cancel(reserved)
+			}
+		}
+		if err == nil && az.Cache != nil {
+			// a failing cache must not fail the request, the audio is simply synthesized again next time.
+			if err := az.Cache.Set(key, result.Audio); err != nil {
+				log.Printf("failed to cache synthesized audio, %v", err)
+			}
+		}
+		return result, err
+	}
+
+	var result *SynthesisResult
+	var err error
+	if az.Coalesce {
+		result, err = az.inflight.do(ctx, key, fetch)
+	} else {
+		result, err = fetch(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return az.postProcess(result)
+}
+
+// StatusError is returned when the text-to-speech endpoint answers with an unsuccessful HTTP status code.
+type StatusError struct {
+	StatusCode int
+	Reason     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d - %s", e.StatusCode, e.Reason)
+}
+
+// post sends the SSML `payload` to the endpoint of voice `v` and returns the rendered audio. `speechText` is the text
+// billed for the request.
+func (az *AzureCSTextToSpeech) post(ctx context.Context, v voice, speechText, payload string, audioOutput AudioOutput) (*SynthesisResult, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, bytes.NewBufferString(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -44,8 +163,13 @@ func (az *AzureCSTextToSpeech) SynthesizeWithContext(ctx context.Context, speech
 	request.Header.Set("Authorization", "Bearer "+az.accessToken)
 	request.Header.Set("User-Agent", "azuretts")
 
+	// record the time to first byte of the response, alongside the overall latency.
+	var firstByte time.Time
+	trace := &httptrace.ClientTrace{GotFirstResponseByte: func() { firstByte = time.Now() }}
+	start := time.Now()
+
 	client := &http.Client{}
-	response, err := client.Do(request.WithContext(ctx))
+	response, err := client.Do(request.WithContext(httptrace.WithClientTrace(ctx, trace)))
 	if err != nil {
 		return nil, err
 	}
@@ -56,22 +180,53 @@ func (az *AzureCSTextToSpeech) SynthesizeWithContext(ctx context.Context, speech
 	switch response.StatusCode {
 	case http.StatusOK:
 		// The request was successful; the response body is an audio file.
-		return ioutil.ReadAll(response.Body)
+		b, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateAudio(b, response.Header.Get("Content-Type"), audioOutput); err != nil {
+			return nil, err
+		}
+		result := &SynthesisResult{
+			Audio:            b,
+			AudioOutput:      audioOutput,
+			Voice:            v.name,
+			Text:             speechText,
+			RequestID:        requestID(response.Header),
+			BilledCharacters: billableSSML(payload, speechText),
+			Latency:          time.Since(start),
+			Duration:         audioDuration(b, audioOutput),
+		}
+		if !firstByte.IsZero() {
+			result.TimeToFirstByte = firstByte.Sub(start)
+		}
+		return result, nil
 	case http.StatusBadRequest:
-		return nil, fmt.Errorf("%d - A required parameter is missing, empty, or null. Or, the value passed to either a required or optional parameter is invalid. A common issue is a header that is too long", response.StatusCode)
+		return nil, &StatusError{StatusCode: response.StatusCode, Reason: "A required parameter is missing, empty, or null. Or, the value passed to either a required or optional parameter is invalid. A common issue is a header that is too long"}
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("%d - The request is not authorized. Check to make sure your subscription key or token is valid and in the correct region", response.StatusCode)
+		return nil, &StatusError{StatusCode: response.StatusCode, Reason: "The request is not authorized. Check to make sure your subscription key or token is valid and in the correct region"}
 	case http.StatusRequestEntityTooLarge:
-		return nil, fmt.Errorf("%d - The SSML input is longer than 1024 characters", response.StatusCode)
+		return nil, &StatusError{StatusCode: response.StatusCode, Reason: "The SSML input is longer than 1024 characters"}
 	case http.StatusUnsupportedMediaType:
-		return nil, fmt.Errorf("%d - It's possible that the wrong Content-Type was provided. Content-Type should be set to application/ssml+xml", response.StatusCode)
+		return nil, &StatusError{StatusCode: response.StatusCode, Reason: "It's possible that the wrong Content-Type was provided. Content-Type should be set to application/ssml+xml"}
 	case http.StatusTooManyRequests:
-		return nil, fmt.Errorf("%d - You have exceeded the quota or rate of requests allowed for your subscription", response.StatusCode)
+		return nil, &StatusError{StatusCode: response.StatusCode, Reason: "You have exceeded the quota or rate of requests allowed for your subscription"}
 	case http.StatusBadGateway:
-		return nil, fmt.Errorf("%d - Network or server-side issue. May also indicate invalid headers", response.StatusCode)
+		return nil, &StatusError{StatusCode: response.StatusCode, Reason: "Network or server-side issue. May also indicate invalid headers"}
 	}
 
-	return nil, fmt.Errorf("%d - received unexpected HTTP status code", response.StatusCode)
+	return nil, &StatusError{StatusCode: response.StatusCode, Reason: "received unexpected HTTP status code"}
+}
+
+// postProcess applies the PostProcessors to `result`.
+func (az *AzureCSTextToSpeech) postProcess(result *SynthesisResult) (*SynthesisResult, error) {
+	var err error
+	for _, process := range az.PostProcessors {
+		if result, err = process(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
 // Synthesize directs to SynthesizeWithContext. A new context.Withtimeout is created with the timeout as defined by synthesizeActionTimeout
@@ -136,10 +291,21 @@ func (az *AzureCSTextToSpeech) startRefresher() chan bool {
 // AzureCSTextToSpeech stores configuration and state information for the TTS client.
 type AzureCSTextToSpeech struct {
 	accessToken         string // is the auth token received from `TokenRefreshAPI`. Used in the Authorization: Bearer header.
+	batchSynthesisURL   string
+	Breaker             *CircuitBreaker // fails requests fast while Azure is failing when set, see NewCircuitBreaker.
+	Cache               Cache           // serves repeated requests without contacting Azure when set, see NewMemoryCache and NewDiskCache.
+	Coalesce            bool            // identical concurrent requests share a single upstream request and its result.
+	customVoiceURL      string          // base endpoint for Custom Neural Voice deployments, `?deploymentId=` is appended per voice.
+	customVoices        map[string]CustomVoice
+	inflight            inflightGroup
+	mu                  sync.RWMutex    // guards customVoices.
+	PostProcessors      []PostProcessor // applied in order to the result of every successful synthesis request.
 	RegionVoiceMap      RegionVoiceMap
-	SubscriptionKey     string    // API key for Azure's Congnitive Speech services
-	TokenRefreshDoneCh  chan bool // channel to stop the token refresh goroutine.
+	Scheduler           *Scheduler // orders and bounds the upstream requests when set, see WithPriority.
+	SubscriptionKey     string     // API key for Azure's Congnitive Speech services
+	TokenRefreshDoneCh  chan bool  // channel to stop the token refresh goroutine.
 	tokenRefreshURL     string
+	Usage               *UsageMeter // accounts the billed characters and enforces a monthly budget when set.
 	voiceServiceListURL string
 	textToSpeechURL     string
 }
@@ -150,9 +316,17 @@ func New(subscriptionKey string, region Region) (*AzureCSTextToSpeech, error) {
 		SubscriptionKey: subscriptionKey,
 	}
 
-	az.textToSpeechURL = fmt.Sprintf(textToSpeechAPI, region)
-	az.tokenRefreshURL = fmt.Sprintf(tokenRefreshAPI, region)
-	az.voiceServiceListURL = fmt.Sprintf(voiceListAPI, region)
+	if !region.IsValid() {
+		return nil, fmt.Errorf("unsupported region, %s", region)
+	}
+
+	// sovereign cloud regions are served from their own set of endpoints.
+	api := region.api()
+	az.textToSpeechURL = fmt.Sprintf(api.textToSpeech, region)
+	az.tokenRefreshURL = fmt.Sprintf(api.tokenRefresh, region)
+	az.voiceServiceListURL = fmt.Sprintf(api.voiceList, region)
+	az.customVoiceURL = fmt.Sprintf(api.customVoice, region)
+	az.batchSynthesisURL = fmt.Sprintf(api.batchSynthesis, region)
 
 	// api requires that the token is refreshed every 10 mintutes.
 	// We will do this task in the background every ~9 minutes.
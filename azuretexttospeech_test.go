@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/jesseward/azuretexttospeech/wav"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,9 +27,10 @@ func TestSynthesize(t *testing.T) {
 	assert.Error(t, err, "should raise an error")
 	assert.Nil(t, payload, "payload should be nil")
 
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
 	ts := httptest.NewServer(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte("SYS4096"))
+			w.Write(audio)
 		}),
 	)
 	defer ts.Close()
@@ -37,7 +39,7 @@ func TestSynthesize(t *testing.T) {
 	// request should now be successful with a valid locale and gender.
 	payload, err = az.Synthesize("SYS4096", LocaleDeCH, GenderMale, AudioRIFF8Bit8kHzMonoPCM)
 	assert.NoError(t, err)
-	assert.Equal(t, payload, []byte("SYS4096"))
+	assert.Equal(t, payload, audio)
 }
 
 // TestRefreshToken validates logic for fetching of the refreshToken
@@ -0,0 +1,318 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency is the number of requests SynthesizeBatch keeps in flight unless configured otherwise.
+const DefaultBatchConcurrency = 8
+
+// DefaultBatchRate is the number of requests per second SynthesizeBatch starts unless configured otherwise, matching
+// the default quota of 200 requests per 10 seconds of a standard (S0) Speech resource.
+// See: https://docs.microsoft.com/en-us/azure/cognitive-services/speech-service/speech-services-quotas-and-limits
+const DefaultBatchRate = 20.0
+
+// BatchJob is a single synthesis request within a batch, see SynthesizeBatch.
+type BatchJob struct {
+	Key string // identifies the job and its output, e.g. a row number or file name.
+	// Text is rendered with the voice selected by Voice, or by Locale and Gender when Voice is empty.
+	Text string
+	// SSML is a complete SSML document sent as is, on the endpoint of Voice or, when empty, of its first voice element.
+	// When set it takes precedence over Text, Locale and Gender.
+	SSML        string
+	Voice       string // name of a stock or custom voice, resolved as by SynthesizeVoiceWithContext.
+	Locale      Locale
+	Gender      Gender
+	AudioOutput AudioOutput
+}
+
+// BatchJobResult is the outcome of a BatchJob.
+type BatchJobResult struct {
+	Job     BatchJob
+	Result  *SynthesisResult // nil when the job failed or was skipped.
+	Err     error
+	Skipped bool // the job completed in an earlier run, see BatchOptions.Checkpoint.
+}
+
+// BatchProgress reports the completion of a job within a batch.
+type BatchProgress struct {
+	BatchJobResult
+	Completed int // jobs finished or skipped so far, including this one.
+	Failed    int // jobs failed so far.
+	Total     int
+}
+
+// BatchOptions configures SynthesizeBatch. The zero value runs DefaultBatchConcurrency requests at a time, started at
+// no more than DefaultBatchRate requests per second.
+type BatchOptions struct {
+	Concurrency int // maximum number of requests in flight.
+	// Rate is the maximum number of requests started per second, a negative rate disables the limit. It is ignored
+	// when the client has a Scheduler, which paces the upstream requests of every caller itself.
+	Rate float64
+	// JobTimeout bounds each request, defaulting to the timeout of Synthesize.
+	JobTimeout time.Duration
+	// Progress is called as each job completes. Calls are never concurrent, and are made in order of completion
+	// rather than the order of the jobs.
+	Progress func(BatchProgress)
+	// Output receives the result of each successful job, e.g. to write the audio to storage, see DirectoryOutput. A
+	// failing Output fails the job. Once written, the audio is released rather than retained in the summary,
+	// keeping the memory used by large batches flat.
+	Output func(key string, result *SynthesisResult) error
+	// Checkpoint, when set, records the outcome of every job. Jobs that completed in an earlier run and are unchanged
+	// since are skipped, so that an interrupted batch can be rerun without paying twice for its finished jobs.
+	Checkpoint *Checkpoint
+}
+
+func (o BatchOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultBatchConcurrency
+	}
+	return o.Concurrency
+}
+
+func (o BatchOptions) rate() float64 {
+	if o.Rate == 0 {
+		return DefaultBatchRate
+	}
+	return o.Rate
+}
+
+func (o BatchOptions) jobTimeout() time.Duration {
+	if o.JobTimeout <= 0 {
+		return synthesizeActionTimeout
+	}
+	return o.JobTimeout
+}
+
+// BatchSummary is the outcome of SynthesizeBatch.
+type BatchSummary struct {
+	Results          []BatchJobResult // in the order of the jobs.
+	Succeeded        int
+	Failed           int
+	Skipped          int
+	BilledCharacters int
+	Elapsed          time.Duration
+}
+
+// Errors returns the results of the failed jobs.
+func (s *BatchSummary) Errors() []BatchJobResult {
+	var failed []BatchJobResult
+	for _, r := range s.Results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// SynthesizeBatch renders `jobs` using a bounded number of concurrent requests, started no faster than the configured
+// rate. A failing job does not stop the batch; its error is recorded in the summary. When `ctx` is cancelled the jobs
+// not yet started fail with the error of the context, which is also returned alongside the summary. Unless `ctx`
+// carries a priority, the jobs are scheduled as PriorityBulk, see WithPriority.
+func (az *AzureCSTextToSpeech) SynthesizeBatch(ctx context.Context, jobs []BatchJob, opts BatchOptions) (*BatchSummary, error) {
+	start := time.Now()
+	if _, ok := ctx.Value(priorityKey{}).(Priority); !ok {
+		ctx = WithPriority(ctx, PriorityBulk)
+	}
+	limiter := newRateLimiter(opts.rate())
+	if az.Scheduler != nil {
+		// the scheduler paces the requests, limiting them here as well would throttle the batch twice.
+		limiter = nil
+	}
+	summary := &BatchSummary{Results: make([]BatchJobResult, len(jobs))}
+	completed := 0
+	report := func(r BatchJobResult) {
+		completed++
+		if opts.Progress != nil {
+			opts.Progress(BatchProgress{BatchJobResult: r, Completed: completed, Failed: summary.Failed, Total: len(jobs)})
+		}
+	}
+
+	var pending []int
+	for i, job := range jobs {
+		if opts.Checkpoint != nil && opts.Checkpoint.Completed(job) {
+			summary.Results[i] = BatchJobResult{Job: job, Skipped: true}
+			summary.Skipped++
+			report(summary.Results[i])
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	type completion struct {
+		index int
+		BatchJobResult
+	}
+	indexes := make(chan int)
+	completions := make(chan completion)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.concurrency() && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result, err := az.runBatchJob(ctx, limiter, jobs[i], opts)
+				completions <- completion{i, BatchJobResult{Job: jobs[i], Result: result, Err: err}}
+			}
+		}()
+	}
+	go func() {
+		for _, i := range pending {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+		close(completions)
+	}()
+
+	for c := range completions {
+		summary.Results[c.index] = c.BatchJobResult
+		if c.Err != nil {
+			summary.Failed++
+		} else {
+			summary.Succeeded++
+			summary.BilledCharacters += c.Result.BilledCharacters
+		}
+		report(c.BatchJobResult)
+	}
+	summary.Elapsed = time.Since(start)
+	return summary, ctx.Err()
+}
+
+// runBatchJob renders `job` once the rate limit allows, hands the result to the Output of `opts` and records the
+// outcome in its Checkpoint.
+func (az *AzureCSTextToSpeech) runBatchJob(ctx context.Context, limiter *rateLimiter, job BatchJob, opts BatchOptions) (*SynthesisResult, error) {
+	result, err := az.renderBatchJob(ctx, limiter, job, opts)
+	// jobs abandoned as the batch was cancelled are left for the next run to retry, they did not fail.
+	if opts.Checkpoint != nil && (err == nil || ctx.Err() == nil) {
+		// a failing checkpoint must not fail the job, at worst the job is synthesized again by the next run.
+		if err := opts.Checkpoint.Record(job, result, err); err != nil {
+			log.Printf("failed to checkpoint batch job %s, %v", job.Key, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Output != nil {
+		// the result may be shared with the cache or coalesced callers, release the audio from a copy.
+		released := *result
+		released.Audio = nil
+		result = &released
+	}
+	return result, nil
+}
+
+// renderBatchJob renders `job` once the rate limit allows and hands the result to the Output of `opts`.
+func (az *AzureCSTextToSpeech) renderBatchJob(ctx context.Context, limiter *rateLimiter, job BatchJob, opts BatchOptions) (*SynthesisResult, error) {
+	if err := limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.jobTimeout())
+	defer cancel()
+
+	var result *SynthesisResult
+	var err error
+	switch {
+	case job.SSML != "":
+		result, err = az.synthesizeSSML(ctx, job.SSML, job.Voice, job.AudioOutput)
+	case job.Voice != "":
+		result, err = az.SynthesizeVoiceResultWithContext(ctx, job.Text, job.Voice, job.AudioOutput)
+	default:
+		result, err = az.SynthesizeResultWithContext(ctx, job.Text, job.Locale, job.Gender, job.AudioOutput)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Output != nil {
+		if err := opts.Output(job.Key, result); err != nil {
+			return nil, fmt.Errorf("unable to write output %s, %v", job.Key, err)
+		}
+	}
+	return result, nil
+}
+
+// DirectoryOutput returns a BatchOptions.Output writing the audio of each job to `dir`, named after the key of the
+// job with the file extension of its AudioOutput. Keys may contain slashes to write into subdirectories, which are
+// created as needed, but may not escape `dir`.
+func DirectoryOutput(dir string) func(key string, result *SynthesisResult) error {
+	return func(key string, result *SynthesisResult) error {
+		name := filepath.Join(dir, filepath.FromSlash(key)+result.AudioOutput.Format().Extension)
+		if rel, err := filepath.Rel(dir, name); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("key %q escapes the output directory", key)
+		}
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(name, result.Audio, 0644)
+	}
+}
+
+// rateLimiter spaces the start of requests evenly to honour a number of requests per second. A nil limiter does not
+// limit.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request.
+}
+
+// newRateLimiter returns a limiter allowing `perSecond` requests per second, or nil when `perSecond` is not positive.
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request may start, or returns the error of `ctx` when it is done first.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take starts a request at `now` when the rate allows, returning zero, or returns how long to wait otherwise. A nil
+// limiter always allows the request.
+func (l *rateLimiter) take(now time.Time) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.next.After(now) {
+		return l.next.Sub(now)
+	}
+	l.next = now.Add(l.interval)
+	return 0
+}
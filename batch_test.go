@@ -0,0 +1,190 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchServer answers every request with `audio`, failing those mentioning "fail", and records the peak number of
+// concurrent requests.
+func batchServer(audio []byte, peak *int32) *httptest.Server {
+	var active int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(peak)
+			if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+				break
+			}
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		time.Sleep(5 * time.Millisecond)
+		if strings.Contains(string(b), "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write(audio)
+	}))
+}
+
+func TestSynthesizeBatch(t *testing.T) {
+	var peak int32
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	ts := batchServer(audio, &peak)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+	}
+	var jobs []BatchJob
+	for i := 0; i < 20; i++ {
+		jobs = append(jobs, BatchJob{Key: string(rune('a' + i)), Text: "line", Locale: LocaleEnUS, Gender: GenderFemale, AudioOutput: AudioRIFF8Bit8kHzMonoPCM})
+	}
+	jobs[3].Text = "fail"
+	jobs[7] = BatchJob{Key: "ssml", SSML: "<speak><voice name='en-US-JennyNeural'>marked up</voice></speak>", AudioOutput: AudioRIFF8Bit8kHzMonoPCM}
+	jobs[9] = BatchJob{Key: "voice", Text: "line", Voice: "en-US-JennyNeural", AudioOutput: AudioRIFF8Bit8kHzMonoPCM}
+	jobs[11].Voice = "UnknownNeural"
+
+	var progress []BatchProgress
+	summary, err := az.SynthesizeBatch(context.Background(), jobs, BatchOptions{
+		Concurrency: 3,
+		Rate:        -1,
+		Progress:    func(p BatchProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 18, summary.Succeeded)
+	assert.Equal(t, 2, summary.Failed)
+	assert.Equal(t, 17*len("line")+len("marked up"), summary.BilledCharacters)
+	assert.LessOrEqual(t, peak, int32(3))
+
+	for i, r := range summary.Results {
+		assert.Equal(t, jobs[i].Key, r.Job.Key, "results follow the order of the jobs")
+	}
+	assert.Error(t, summary.Results[3].Err)
+	assert.Nil(t, summary.Results[3].Result)
+	assert.Error(t, summary.Results[11].Err)
+	assert.Equal(t, audio, summary.Results[7].Result.Audio)
+	failed := summary.Errors()
+	assert.Equal(t, 2, len(failed))
+	assert.Equal(t, "d", failed[0].Job.Key)
+
+	assert.Equal(t, len(jobs), len(progress))
+	for i, p := range progress {
+		assert.Equal(t, i+1, p.Completed)
+		assert.Equal(t, len(jobs), p.Total)
+	}
+	assert.Equal(t, 2, progress[len(progress)-1].Failed)
+}
+
+func TestSynthesizeBatchOutput(t *testing.T) {
+	var peak int32
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	ts := batchServer(audio, &peak)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+	}
+	dir, err := ioutil.TempDir("", "azuretts-batch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	jobs := []BatchJob{
+		{Key: "prompts/welcome", Text: "welcome", Voice: "en-US-JennyNeural", AudioOutput: AudioRIFF8Bit8kHzMonoPCM},
+		{Key: "../escape", Text: "escape", Voice: "en-US-JennyNeural", AudioOutput: AudioRIFF8Bit8kHzMonoPCM},
+	}
+	summary, err := az.SynthesizeBatch(context.Background(), jobs, BatchOptions{Output: DirectoryOutput(dir)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Succeeded)
+	assert.Nil(t, summary.Results[0].Result.Audio, "written audio is released")
+	assert.Error(t, summary.Results[1].Err)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "prompts", "welcome.wav"))
+	assert.NoError(t, err)
+	assert.Equal(t, audio, b)
+}
+
+func TestSynthesizeBatchCancel(t *testing.T) {
+	var peak int32
+	ts := batchServer(riffFixture(wav.FormatMuLaw, 8000, 8, 4096), &peak)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+	}
+	jobs := make([]BatchJob, 50)
+	for i := range jobs {
+		jobs[i] = BatchJob{Text: "line", Locale: LocaleEnUS, Gender: GenderFemale, AudioOutput: AudioRIFF8Bit8kHzMonoPCM}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	summary, err := az.SynthesizeBatch(ctx, jobs, BatchOptions{
+		Concurrency: 2,
+		Progress: func(p BatchProgress) {
+			if p.Completed == 5 {
+				cancel()
+			}
+		},
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, len(jobs), summary.Succeeded+summary.Failed)
+	assert.True(t, summary.Failed > 40)
+}
+
+func TestSynthesizeBatchScheduler(t *testing.T) {
+	var peak int32
+	ts := batchServer(riffFixture(wav.FormatMuLaw, 8000, 8, 4096), &peak)
+	defer ts.Close()
+
+	scheduler, err := NewScheduler(SchedulerOptions{Concurrency: 4, Rate: -1})
+	assert.NoError(t, err)
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+		Scheduler:       scheduler,
+	}
+	jobs := make([]BatchJob, 5)
+	for i := range jobs {
+		jobs[i] = BatchJob{Text: "line", Locale: LocaleEnUS, Gender: GenderFemale, AudioOutput: AudioRIFF8Bit8kHzMonoPCM}
+	}
+
+	// the scheduler paces the requests, the rate of the batch is not applied on top of it.
+	start := time.Now()
+	summary, err := az.SynthesizeBatch(context.Background(), jobs, BatchOptions{Rate: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, summary.Succeeded)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, int64(5), scheduler.Stats()[PriorityBulk].Served)
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(100)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, l.wait(context.Background()))
+	}
+	assert.True(t, time.Since(start) >= 50*time.Millisecond, "requests are spaced by 10ms")
+
+	assert.Nil(t, newRateLimiter(-1))
+	assert.NoError(t, newRateLimiter(-1).wait(context.Background()))
+
+	slow := newRateLimiter(0.1)
+	assert.NoError(t, slow.wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, slow.wait(ctx))
+}
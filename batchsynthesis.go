@@ -0,0 +1,449 @@
+package azuretexttospeech
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// batchSynthesisAPI is the endpoint of the batch synthesis API, which renders long inputs such as audiobooks
+// asynchronously. See `cloudAPIs` for the sovereign cloud equivalents.
+// See: https://learn.microsoft.com/en-us/azure/ai-services/speech-service/batch-synthesis
+const batchSynthesisAPI = "https://%s.api.cognitive.microsoft.com/texttospeech/batchsyntheses"
+
+// batchSynthesisAPIVersion is the version of the batch synthesis API spoken by the client.
+const batchSynthesisAPIVersion = "2024-04-01"
+
+// DefaultBatchSynthesisPollInterval is the interval at which WaitBatchSynthesis polls the status of a job unless
+// configured otherwise.
+const DefaultBatchSynthesisPollInterval = 10 * time.Second
+
+// BatchSynthesisStatus is the state of a batch synthesis job.
+type BatchSynthesisStatus string
+
+const (
+	BatchSynthesisNotStarted BatchSynthesisStatus = "NotStarted"
+	BatchSynthesisRunning    BatchSynthesisStatus = "Running"
+	BatchSynthesisSucceeded  BatchSynthesisStatus = "Succeeded"
+	BatchSynthesisFailed     BatchSynthesisStatus = "Failed"
+)
+
+// Done returns true once the job has succeeded or failed.
+func (s BatchSynthesisStatus) Done() bool {
+	return s == BatchSynthesisSucceeded || s == BatchSynthesisFailed
+}
+
+// BatchSynthesisRequest describes a batch synthesis job, see SubmitBatchSynthesis.
+type BatchSynthesisRequest struct {
+	// ID identifies the job, made of 3 to 64 letters, digits, '-', '_' and '.'. A random ID is generated when empty.
+	ID          string
+	Description string
+	// Inputs are SSML documents, or plain texts when Voice is set, each rendered into its own audio file.
+	Inputs []string
+	// Voice is the name of the stock or custom voice speaking plain text inputs. Registered custom voices, see
+	// RegisterCustomVoice, are passed along with their deployment when named by Voice or mentioned by the inputs.
+	Voice              string
+	AudioOutput        AudioOutput
+	WordBoundaries     bool          // write the offset of each word alongside the audio.
+	SentenceBoundaries bool          // write the offset of each sentence alongside the audio.
+	Concatenate        bool          // join the audio of every input into a single file.
+	TimeToLive         time.Duration // how long the job and its results are kept once finished, rounded to hours.
+}
+
+// BatchSynthesis is the state of a batch synthesis job as reported by Azure.
+type BatchSynthesis struct {
+	ID          string                   `json:"id"`
+	Description string                   `json:"description,omitempty"`
+	Status      BatchSynthesisStatus     `json:"status"`
+	Created     time.Time                `json:"createdDateTime"`
+	LastAction  time.Time                `json:"lastActionDateTime"`
+	Outputs     BatchSynthesisOutputs    `json:"outputs"`
+	Properties  BatchSynthesisProperties `json:"properties"`
+}
+
+// BatchSynthesisOutputs locates the results of a batch synthesis job.
+type BatchSynthesisOutputs struct {
+	Result string `json:"result"` // URL of the ZIP archive holding the results, set once the job succeeded.
+}
+
+// BatchSynthesisProperties holds the details of a batch synthesis job.
+type BatchSynthesisProperties struct {
+	SizeInBytes            int64                      `json:"sizeInBytes"`
+	DurationInMilliseconds int64                      `json:"durationInMilliseconds"`
+	SucceededAudioCount    int                        `json:"succeededAudioCount"`
+	FailedAudioCount       int                        `json:"failedAudioCount"`
+	BillingDetails         BatchSynthesisBilling      `json:"billingDetails"`
+	Error                  *BatchSynthesisErrorDetail `json:"error,omitempty"`
+}
+
+// BatchSynthesisBilling holds the characters billed for a batch synthesis job.
+type BatchSynthesisBilling struct {
+	NeuralCharacters       int64 `json:"neuralCharacters"`
+	CustomNeuralCharacters int64 `json:"customNeuralCharacters"`
+}
+
+// BatchSynthesisErrorDetail describes why a batch synthesis job or request failed.
+type BatchSynthesisErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// batchSynthesisPayload is the body of the request creating a job.
+type batchSynthesisPayload struct {
+	Description     string                 `json:"description,omitempty"`
+	InputKind       string                 `json:"inputKind"`
+	Inputs          []batchSynthesisInput  `json:"inputs"`
+	SynthesisConfig *batchSynthesisConfig  `json:"synthesisConfig,omitempty"`
+	CustomVoices    map[string]string      `json:"customVoices,omitempty"`
+	Properties      batchSynthesisSettings `json:"properties"`
+}
+
+type batchSynthesisInput struct {
+	Content string `json:"content"`
+}
+
+type batchSynthesisConfig struct {
+	Voice string `json:"voice"`
+}
+
+type batchSynthesisSettings struct {
+	OutputFormat            string `json:"outputFormat"`
+	WordBoundaryEnabled     bool   `json:"wordBoundaryEnabled"`
+	SentenceBoundaryEnabled bool   `json:"sentenceBoundaryEnabled"`
+	ConcatenateResult       bool   `json:"concatenateResult"`
+	TimeToLiveInHours       int    `json:"timeToLiveInHours,omitempty"`
+}
+
+// SubmitBatchSynthesis creates a batch synthesis job rendering the inputs of `req`, returning the state of the new job.
+func (az *AzureCSTextToSpeech) SubmitBatchSynthesis(ctx context.Context, req BatchSynthesisRequest) (*BatchSynthesis, error) {
+	if len(req.Inputs) == 0 {
+		return nil, fmt.Errorf("batch synthesis requires at least one input")
+	}
+	if !req.AudioOutput.IsValid() {
+		return nil, fmt.Errorf("unsupported audio output, %s", req.AudioOutput)
+	}
+	id := req.ID
+	if id == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		id = hex.EncodeToString(b)
+	}
+
+	payload := batchSynthesisPayload{
+		Description: req.Description,
+		InputKind:   "SSML",
+		Properties: batchSynthesisSettings{
+			OutputFormat:            req.AudioOutput.String(),
+			WordBoundaryEnabled:     req.WordBoundaries,
+			SentenceBoundaryEnabled: req.SentenceBoundaries,
+			ConcatenateResult:       req.Concatenate,
+			TimeToLiveInHours:       int((req.TimeToLive + time.Hour/2) / time.Hour),
+		},
+	}
+	if req.Voice != "" {
+		payload.InputKind = "PlainText"
+		payload.SynthesisConfig = &batchSynthesisConfig{Voice: req.Voice}
+	}
+	for _, in := range req.Inputs {
+		payload.Inputs = append(payload.Inputs, batchSynthesisInput{Content: in})
+	}
+	voices := map[string]bool{req.Voice: true}
+	if req.Voice == "" {
+		voices = ssmlVoices(req.Inputs)
+	}
+	for _, cv := range az.CustomVoices() {
+		if voices[cv.Name] {
+			if payload.CustomVoices == nil {
+				payload.CustomVoices = map[string]string{}
+			}
+			payload.CustomVoices[cv.Name] = cv.DeploymentID
+		}
+	}
+
+	var s BatchSynthesis
+	if err := az.batchSynthesisRequest(ctx, http.MethodPut, az.batchSynthesisEndpoint(id), payload, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ssmlVoices returns the names of the voice elements of the SSML `inputs`. Inputs that cannot be parsed contribute the
+// voices found up to the error, the service reports the error itself.
+func ssmlVoices(inputs []string) map[string]bool {
+	voices := map[string]bool{}
+	for _, in := range inputs {
+		d := xml.NewDecoder(strings.NewReader(in))
+		for {
+			tok, err := d.RawToken()
+			if err != nil {
+				break
+			}
+			if t, ok := tok.(xml.StartElement); ok && t.Name.Local == "voice" {
+				for _, a := range t.Attr {
+					if a.Name.Local == "name" {
+						voices[a.Value] = true
+					}
+				}
+			}
+		}
+	}
+	return voices
+}
+
+// GetBatchSynthesis returns the state of the batch synthesis job `id`.
+func (az *AzureCSTextToSpeech) GetBatchSynthesis(ctx context.Context, id string) (*BatchSynthesis, error) {
+	var s BatchSynthesis
+	if err := az.batchSynthesisRequest(ctx, http.MethodGet, az.batchSynthesisEndpoint(id), nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListBatchSyntheses returns every batch synthesis job of the subscription, following the pages of the listing.
+func (az *AzureCSTextToSpeech) ListBatchSyntheses(ctx context.Context) ([]BatchSynthesis, error) {
+	var all []BatchSynthesis
+	next := az.batchSynthesisEndpoint("")
+	for next != "" {
+		var page struct {
+			Value    []BatchSynthesis `json:"value"`
+			NextLink string           `json:"nextLink"`
+		}
+		if err := az.batchSynthesisRequest(ctx, http.MethodGet, next, nil, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Value...)
+		next = page.NextLink
+	}
+	return all, nil
+}
+
+// DeleteBatchSynthesis deletes the batch synthesis job `id` along with its results.
+func (az *AzureCSTextToSpeech) DeleteBatchSynthesis(ctx context.Context, id string) error {
+	return az.batchSynthesisRequest(ctx, http.MethodDelete, az.batchSynthesisEndpoint(id), nil, nil)
+}
+
+// WaitBatchSynthesis polls the batch synthesis job `id` every `interval`, or DefaultBatchSynthesisPollInterval when
+// zero, until it has succeeded or failed. The final state is returned; a failed job is not reported as an error.
+func (az *AzureCSTextToSpeech) WaitBatchSynthesis(ctx context.Context, id string, interval time.Duration) (*BatchSynthesis, error) {
+	if interval <= 0 {
+		interval = DefaultBatchSynthesisPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s, err := az.GetBatchSynthesis(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if s.Status.Done() {
+			return s, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// batchSynthesisEndpoint returns the URL of job `id`, or of the collection of jobs when `id` is empty.
+func (az *AzureCSTextToSpeech) batchSynthesisEndpoint(id string) string {
+	u := az.batchSynthesisURL
+	if id != "" {
+		u += "/" + url.PathEscape(id)
+	}
+	return u + "?api-version=" + batchSynthesisAPIVersion
+}
+
+// batchSynthesisRequest sends `body`, when not nil, as JSON to `endpoint` and decodes the response into `out`, when not
+// nil. The request is authenticated with the subscription key.
+func (az *AzureCSTextToSpeech) batchSynthesisRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set("Ocp-Apim-Subscription-Key", az.SubscriptionKey)
+	request.Header.Set("User-Agent", "azuretts")
+
+	client := &http.Client{}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	b, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		var e struct {
+			Error BatchSynthesisErrorDetail `json:"error"`
+		}
+		if json.Unmarshal(b, &e) == nil && e.Error.Message != "" {
+			return &StatusError{StatusCode: response.StatusCode, Reason: fmt.Sprintf("batch synthesis request failed, %s: %s", e.Error.Code, e.Error.Message)}
+		}
+		return &StatusError{StatusCode: response.StatusCode, Reason: "batch synthesis request failed"}
+	}
+	if out == nil || len(b) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("unable to decode batch synthesis response, %v", err)
+	}
+	return nil
+}
+
+// Boundary is the position of a word or sentence within synthesized audio.
+type Boundary struct {
+	Text     string
+	Offset   time.Duration
+	Duration time.Duration
+}
+
+// UnmarshalJSON reads a boundary as written by the batch synthesis API, with offsets in milliseconds.
+func (b *Boundary) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Text        string  `json:"Text"`
+		AudioOffset float64 `json:"AudioOffset"`
+		Duration    float64 `json:"Duration"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	b.Text = v.Text
+	b.Offset = time.Duration(v.AudioOffset * float64(time.Millisecond))
+	b.Duration = time.Duration(v.Duration * float64(time.Millisecond))
+	return nil
+}
+
+// BatchSynthesisAudio is a single audio file of the results of a batch synthesis job.
+type BatchSynthesisAudio struct {
+	Name               string // file name within the archive, e.g. "0001.wav".
+	Audio              []byte
+	WordBoundaries     []Boundary // set when requested by BatchSynthesisRequest.WordBoundaries.
+	SentenceBoundaries []Boundary // set when requested by BatchSynthesisRequest.SentenceBoundaries.
+}
+
+// BatchSynthesisResult holds the unpacked results of a batch synthesis job.
+type BatchSynthesisResult struct {
+	Audio   []BatchSynthesisAudio // in the order of the inputs.
+	Summary json.RawMessage       // the summary.json written by Azure, reporting the outcome of every input.
+}
+
+// DownloadBatchSynthesis downloads and unpacks the results of the succeeded job `s`.
+func (az *AzureCSTextToSpeech) DownloadBatchSynthesis(ctx context.Context, s *BatchSynthesis) (*BatchSynthesisResult, error) {
+	if s.Status != BatchSynthesisSucceeded || s.Outputs.Result == "" {
+		return nil, fmt.Errorf("batch synthesis %s has no results, status %s", s.ID, s.Status)
+	}
+	// the result is served from storage through a signed URL, the subscription key must not be sent along.
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Outputs.Result, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: response.StatusCode, Reason: "unable to download batch synthesis results"}
+	}
+	b, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	return UnpackBatchSynthesis(b)
+}
+
+// UnpackBatchSynthesis reads the results of a batch synthesis job from the ZIP archive `b`.
+func UnpackBatchSynthesis(b []byte) (*BatchSynthesisResult, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read batch synthesis results, %v", err)
+	}
+
+	result := &BatchSynthesisResult{}
+	audio := map[string]*BatchSynthesisAudio{}
+	boundaries := map[string][]byte{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		name := path.Base(f.Name)
+		switch {
+		case name == "summary.json":
+			result.Summary = data
+		case strings.HasSuffix(name, ".word.json") || strings.HasSuffix(name, ".sentence.json"):
+			boundaries[name] = data
+		case strings.HasSuffix(name, ".json"):
+			// other reports are not interpreted.
+		default:
+			audio[strings.TrimSuffix(name, path.Ext(name))] = &BatchSynthesisAudio{Name: name, Audio: data}
+		}
+	}
+
+	for name, data := range boundaries {
+		kind := path.Ext(strings.TrimSuffix(name, ".json"))
+		a, ok := audio[strings.TrimSuffix(name, kind+".json")]
+		if !ok {
+			continue
+		}
+		var bs []Boundary
+		if err := json.Unmarshal(data, &bs); err != nil {
+			return nil, fmt.Errorf("unable to read %s, %v", name, err)
+		}
+		if kind == ".word" {
+			a.WordBoundaries = bs
+		} else {
+			a.SentenceBoundaries = bs
+		}
+	}
+
+	for _, a := range audio {
+		result.Audio = append(result.Audio, *a)
+	}
+	// files are numbered after their input, e.g. 0001.wav.
+	sort.Slice(result.Audio, func(i, j int) bool { return result.Audio[i].Name < result.Audio[j].Name })
+	return result, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s, %v", f.Name, err)
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
@@ -0,0 +1,166 @@
+package azuretexttospeech
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchSynthesisArchive returns a results archive as written by Azure for two inputs.
+func batchSynthesisArchive(t *testing.T, audio []byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string][]byte{
+		"0002.wav":           audio,
+		"0001.wav":           audio,
+		"0001.word.json":     []byte(`[{"Text":"Chapter","AudioOffset":50,"Duration":412.5},{"Text":"one","AudioOffset":475,"Duration":200}]`),
+		"0001.sentence.json": []byte(`[{"Text":"Chapter one.","AudioOffset":50,"Duration":625}]`),
+		"summary.json":       []byte(`{"jobID":"book","status":"Succeeded","results":[]}`),
+	}
+	for name, b := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		w.Write(b)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestBatchSynthesis(t *testing.T) {
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	polls := 0
+	var created batchSynthesisPayload
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/results.zip" {
+			assert.Empty(t, r.Header.Get("Ocp-Apim-Subscription-Key"), "the key is not sent to storage")
+			w.Write(batchSynthesisArchive(t, audio))
+			return
+		}
+		assert.Equal(t, "SYS64738", r.Header.Get("Ocp-Apim-Subscription-Key"))
+		assert.Equal(t, batchSynthesisAPIVersion, r.URL.Query().Get("api-version"))
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/batchsyntheses/book":
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(b, &created))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"book","status":"NotStarted","createdDateTime":"2024-05-01T10:00:00Z"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/batchsyntheses/book":
+			polls++
+			if polls < 3 {
+				w.Write([]byte(`{"id":"book","status":"Running"}`))
+				return
+			}
+			w.Write([]byte(`{"id":"book","status":"Succeeded","outputs":{"result":"` + ts.URL + `/results.zip"},
+				"properties":{"succeededAudioCount":2,"billingDetails":{"neuralCharacters":1234}}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/batchsyntheses" && r.URL.Query().Get("skip") == "":
+			w.Write([]byte(`{"value":[{"id":"book","status":"Running"}],"nextLink":"` + ts.URL + `/batchsyntheses?api-version=` + batchSynthesisAPIVersion + `&skip=1"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/batchsyntheses":
+			w.Write([]byte(`{"value":[{"id":"other","status":"Failed","properties":{"error":{"code":"InvalidInput","message":"bad SSML"}}}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/batchsyntheses/book":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"NotFound","message":"unknown synthesis"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{SubscriptionKey: "SYS64738", batchSynthesisURL: ts.URL + "/batchsyntheses", customVoiceURL: ts.URL}
+	assert.NoError(t, az.RegisterCustomVoice(CustomVoice{Name: "NarratorNeural", Locale: LocaleEnUS, DeploymentID: "SYS2064"}))
+	ctx := context.Background()
+
+	s, err := az.SubmitBatchSynthesis(ctx, BatchSynthesisRequest{
+		ID:             "book",
+		Inputs:         []string{"Chapter one.", "Chapter two."},
+		Voice:          "NarratorNeural",
+		AudioOutput:    AudioRIFF8Bit8kHzMonoPCM,
+		WordBoundaries: true,
+		TimeToLive:     48 * time.Hour,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, BatchSynthesisNotStarted, s.Status)
+	assert.Equal(t, "PlainText", created.InputKind)
+	assert.Equal(t, "NarratorNeural", created.SynthesisConfig.Voice)
+	assert.Equal(t, map[string]string{"NarratorNeural": "SYS2064"}, created.CustomVoices)
+	assert.Equal(t, "riff-8khz-8bit-mono-mulaw", created.Properties.OutputFormat)
+	assert.Equal(t, 48, created.Properties.TimeToLiveInHours)
+	assert.Equal(t, 2, len(created.Inputs))
+
+	s, err = az.WaitBatchSynthesis(ctx, "book", time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, BatchSynthesisSucceeded, s.Status)
+	assert.Equal(t, int64(1234), s.Properties.BillingDetails.NeuralCharacters)
+
+	result, err := az.DownloadBatchSynthesis(ctx, s)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result.Audio))
+	assert.Equal(t, "0001.wav", result.Audio[0].Name)
+	assert.Equal(t, audio, result.Audio[1].Audio)
+	assert.Equal(t, []Boundary{
+		{Text: "Chapter", Offset: 50 * time.Millisecond, Duration: 412500 * time.Microsecond},
+		{Text: "one", Offset: 475 * time.Millisecond, Duration: 200 * time.Millisecond},
+	}, result.Audio[0].WordBoundaries)
+	assert.Equal(t, 1, len(result.Audio[0].SentenceBoundaries))
+	assert.Nil(t, result.Audio[1].WordBoundaries)
+	assert.True(t, strings.Contains(string(result.Summary), `"jobID":"book"`))
+
+	all, err := az.ListBatchSyntheses(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(all))
+	assert.Equal(t, "bad SSML", all[1].Properties.Error.Message)
+
+	assert.NoError(t, az.DeleteBatchSynthesis(ctx, "book"))
+	_, err = az.GetBatchSynthesis(ctx, "missing")
+	assert.EqualError(t, err, "404 - batch synthesis request failed, NotFound: unknown synthesis")
+	var se *StatusError
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, http.StatusNotFound, se.StatusCode)
+
+	_, err = az.SubmitBatchSynthesis(ctx, BatchSynthesisRequest{AudioOutput: AudioRIFF8Bit8kHzMonoPCM})
+	assert.Error(t, err, "inputs are required")
+	_, err = az.DownloadBatchSynthesis(ctx, &BatchSynthesis{ID: "book", Status: BatchSynthesisRunning})
+	assert.Error(t, err)
+}
+
+func TestSubmitBatchSynthesisSSML(t *testing.T) {
+	var created batchSynthesisPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(b, &created))
+		assert.Equal(t, 32+len("/batchsyntheses/"), len(r.URL.Path), "a random ID is generated")
+		w.Write([]byte(`{"status":"NotStarted"}`))
+	}))
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{batchSynthesisURL: ts.URL + "/batchsyntheses"}
+	// a custom voice whose name is part of the name of a stock voice is not attached.
+	assert.NoError(t, az.RegisterCustomVoice(CustomVoice{Name: "Jenny", Locale: LocaleEnUS, DeploymentID: "SYS2064"}))
+	assert.NoError(t, az.RegisterCustomVoice(CustomVoice{Name: "NarratorNeural", Locale: LocaleEnUS, DeploymentID: "SYS64738"}))
+	_, err := az.SubmitBatchSynthesis(context.Background(), BatchSynthesisRequest{
+		Inputs:      []string{"<speak version='1.0' xml:lang='en-US'><voice name='en-US-JennyNeural'>Hi</voice></speak>"},
+		AudioOutput: Audio16khz32kbitrateMonoMp3,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "SSML", created.InputKind)
+	assert.Nil(t, created.SynthesisConfig)
+	assert.Nil(t, created.CustomVoices)
+
+	_, err = az.SubmitBatchSynthesis(context.Background(), BatchSynthesisRequest{
+		Inputs:      []string{"<speak version='1.0' xml:lang='en-US'><voice name='NarratorNeural'>Jenny said hi</voice></speak>"},
+		AudioOutput: Audio16khz32kbitrateMonoMp3,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"NarratorNeural": "SYS64738"}, created.CustomVoices)
+}
@@ -0,0 +1,191 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker guarding an endpoint.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // requests flow normally.
+	BreakerOpen                         // requests are refused until the open timeout has passed.
+	BreakerHalfOpen                     // a single probe request is let through to test whether the endpoint recovered.
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+// DefaultBreakerFailureThreshold is the number of consecutive failures opening a CircuitBreaker unless configured
+// otherwise.
+const DefaultBreakerFailureThreshold = 5
+
+// DefaultBreakerOpenTimeout is how long a CircuitBreaker stays open before probing the endpoint, unless configured
+// otherwise.
+const DefaultBreakerOpenTimeout = 30 * time.Second
+
+// CircuitOpenError is returned without contacting Azure while the CircuitBreaker of the client is open.
+type CircuitOpenError struct {
+	State BreakerState
+	Until time.Time // when the breaker lets a probe through, zero while a probe is in flight.
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.Until.IsZero() {
+		return "circuit breaker is half-open, awaiting the outcome of a probe"
+	}
+	return fmt.Sprintf("circuit breaker is open until %s", e.Until.Format(time.RFC3339))
+}
+
+// CircuitBreakerOptions configures NewCircuitBreaker, zero fields use the defaults above.
+type CircuitBreakerOptions struct {
+	FailureThreshold int           // consecutive failures opening the breaker.
+	OpenTimeout      time.Duration // time the breaker stays open before letting a probe through.
+}
+
+// CircuitBreaker opens after a number of consecutive failures, refusing requests for the open timeout before letting a
+// single probe through. A successful probe closes the breaker, a failed probe opens it again. See
+// AzureCSTextToSpeech.Breaker.
+type CircuitBreaker struct {
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int // consecutive failures while closed.
+	openedAt time.Time
+	probing  bool // a probe is in flight while half-open.
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker configured by `opts`.
+func NewCircuitBreaker(opts CircuitBreakerOptions) *CircuitBreaker {
+	b := &CircuitBreaker{threshold: opts.FailureThreshold, openTimeout: opts.OpenTimeout}
+	if b.threshold <= 0 {
+		b.threshold = DefaultBreakerFailureThreshold
+	}
+	if b.openTimeout <= 0 {
+		b.openTimeout = DefaultBreakerOpenTimeout
+	}
+	return b
+}
+
+// allow returns true when a request may be sent, claiming the probe when the breaker is half-open. A request that was
+// allowed must be followed by a call to success, failure or abort.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.claim()
+}
+
+// enter behaves as allow, returning a *CircuitOpenError when the request is refused.
+func (b *CircuitBreaker) enter() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.claim() {
+		return nil
+	}
+	e := &CircuitOpenError{State: b.current()}
+	if e.State == BreakerOpen {
+		e.Until = b.openedAt.Add(b.openTimeout)
+	}
+	return e
+}
+
+// claim implements allow, the caller must hold the lock.
+func (b *CircuitBreaker) claim() bool {
+	switch b.current() {
+	case BreakerClosed:
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	}
+	return false
+}
+
+// record records the outcome of an allowed request from its error: server errors, throttling, timeouts and network
+// failures count as failures, other errors mean the endpoint answered. Requests cancelled by their caller are aborted.
+func (b *CircuitBreaker) record(err error) {
+	switch {
+	case err == nil:
+		b.success()
+	case errors.Is(err, context.Canceled):
+		b.abort()
+	case failover(err):
+		b.failure()
+	default:
+		b.success()
+	}
+}
+
+// success records a successful request, closing the breaker.
+func (b *CircuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure records a failed request, opening the breaker once the threshold is reached or when the probe failed.
+func (b *CircuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.probing = false
+	}
+}
+
+// abort records a request that was allowed but ended without telling anything about the endpoint, such as one
+// cancelled by its caller, releasing the probe.
+func (b *CircuitBreaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// reopensAt returns when an open breaker lets a probe through, zero when the breaker is not open.
+func (b *CircuitBreaker) reopensAt() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.current() != BreakerOpen {
+		return time.Time{}
+	}
+	return b.openedAt.Add(b.openTimeout)
+}
+
+// State returns the current state of the breaker, e.g. for health checks.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current()
+}
+
+// current returns the state, reporting an open breaker whose timeout has passed as half-open. The caller must hold the
+// lock.
+func (b *CircuitBreaker) current() BreakerState {
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.openTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
@@ -0,0 +1,112 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2, OpenTimeout: 20 * time.Millisecond})
+	assert.Equal(t, BreakerClosed, b.State())
+
+	assert.True(t, b.allow())
+	b.failure()
+	assert.True(t, b.allow())
+	b.success()
+	assert.True(t, b.allow())
+	b.failure()
+	assert.Equal(t, BreakerClosed, b.State(), "failures must be consecutive")
+	assert.True(t, b.allow())
+	b.failure()
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.False(t, b.allow())
+
+	time.Sleep(25 * time.Millisecond)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.True(t, b.allow(), "a single probe is let through")
+	assert.False(t, b.allow())
+	b.failure()
+	assert.Equal(t, BreakerOpen, b.State(), "a failed probe opens the breaker again")
+
+	time.Sleep(25 * time.Millisecond)
+	assert.True(t, b.allow())
+	b.abort()
+	assert.True(t, b.allow(), "an aborted probe releases the slot")
+	b.success()
+	assert.Equal(t, BreakerClosed, b.State())
+
+	assert.Equal(t, "half-open", BreakerHalfOpen.String())
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	var status, requests int32
+	ts := regionServer(audio, &status, &requests)
+	defer ts.Close()
+
+	az := regionClient(ts.URL)
+	az.Breaker = NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond})
+	synthesize := func() error {
+		_, err := az.SynthesizeWithContext(context.Background(), "hello", LocaleEnUS, GenderFemale, AudioRIFF8Bit8kHzMonoPCM)
+		return err
+	}
+
+	// client errors tell nothing about the health of the endpoint.
+	atomic.StoreInt32(&status, http.StatusBadRequest)
+	for i := 0; i < 3; i++ {
+		assert.Error(t, synthesize())
+	}
+	assert.Equal(t, BreakerClosed, az.Breaker.State())
+
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	for i := 0; i < 2; i++ {
+		var se *StatusError
+		assert.True(t, errors.As(synthesize(), &se))
+	}
+	assert.Equal(t, BreakerOpen, az.Breaker.State())
+
+	// requests fail fast without reaching the endpoint while the breaker is open.
+	atomic.StoreInt32(&requests, 0)
+	err := synthesize()
+	var oe *CircuitOpenError
+	assert.True(t, errors.As(err, &oe))
+	assert.Equal(t, BreakerOpen, oe.State)
+	assert.False(t, oe.Until.IsZero())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+
+	// once recovered, the probe closes the breaker again.
+	atomic.StoreInt32(&status, 0)
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, BreakerHalfOpen, az.Breaker.State())
+	assert.NoError(t, synthesize())
+	assert.Equal(t, BreakerClosed, az.Breaker.State())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestCircuitBreakerRecord(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Hour})
+	assert.NoError(t, b.enter())
+	b.record(fmt.Errorf("unable to synthesize, %w", context.Canceled))
+	assert.Equal(t, BreakerClosed, b.State(), "cancelled requests are not failures")
+
+	assert.NoError(t, b.enter())
+	b.record(&StatusError{StatusCode: http.StatusTooManyRequests, Reason: "too many requests"})
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.Contains(t, b.enter().Error(), "circuit breaker is open until")
+
+	b = NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Millisecond})
+	assert.NoError(t, b.enter())
+	b.record(context.DeadlineExceeded)
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(t, b.enter(), "the probe is let through")
+	err := b.enter()
+	assert.EqualError(t, err, "circuit breaker is half-open, awaiting the outcome of a probe")
+}
@@ -0,0 +1,139 @@
+package azuretexttospeech
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// cacheKeyVersion is mixed into every CacheKey, allowing the key scheme to change without serving stale entries.
+const cacheKeyVersion = "v1"
+
+// Cache stores synthesized audio keyed by CacheKey, see AzureCSTextToSpeech.Cache. Implementations must be safe for
+// concurrent use, and must not share the audio they are given or return with their callers, which are free to modify it.
+type Cache interface {
+	// Get returns the audio stored under `key`, and false when there is no usable entry.
+	Get(key string) ([]byte, bool)
+	// Set stores `audio` under `key`, replacing any existing entry.
+	Set(key string, audio []byte) error
+	// Stats returns the usage statistics of the cache.
+	Stats() CacheStats
+}
+
+// CacheStats captures the usage of a Cache.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64 // entries removed to respect the size limit, or because they expired or were corrupt.
+	Entries   int
+	Bytes     int64
+}
+
+// HitRatio returns the fraction of lookups served from the cache.
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// CacheKey returns the key identifying the audio rendered from the SSML `payload` by `voice` in `audioOutput` at
+// `endpoint`. The key is the hex encoded SHA-256 hash of the fields.
+func CacheKey(payload, voice string, audioOutput AudioOutput, endpoint string) string {
+	h := sha256.New()
+	for _, s := range []string{cacheKeyVersion, payload, voice, audioOutput.String(), endpoint} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryCache is an in-memory Cache evicting the least recently used entries once it holds more than its size limit.
+type MemoryCache struct {
+	maxBytes int64
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used at the front.
+	stats   CacheStats
+}
+
+type memoryEntry struct {
+	key     string
+	audio   []byte
+	expires time.Time // zero when entries do not expire.
+}
+
+// NewMemoryCache returns a MemoryCache holding up to `maxBytes` of audio, with entries expiring after `ttl`. A zero
+// `maxBytes` or `ttl` disables the respective limit.
+func NewMemoryCache(maxBytes int64, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{maxBytes: maxBytes, ttl: ttl, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if ok {
+		if e := el.Value.(*memoryEntry); !e.expires.IsZero() && time.Now().After(e.expires) {
+			c.remove(el)
+			c.stats.Evictions++
+			ok = false
+		}
+	}
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(el)
+	return append([]byte(nil), el.Value.(*memoryEntry).audio...), true
+}
+
+// Set implements Cache. Audio larger than the size limit is not stored. The entries hold copies of the audio, so that
+// callers and PostProcessors modifying their results in place do not alter the cache.
+func (c *MemoryCache) Set(key string, audio []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	if c.maxBytes > 0 && int64(len(audio)) > c.maxBytes {
+		return nil
+	}
+
+	e := &memoryEntry{key: key, audio: append([]byte(nil), audio...)}
+	if c.ttl > 0 {
+		e.expires = time.Now().Add(c.ttl)
+	}
+	c.entries[key] = c.order.PushFront(e)
+	c.stats.Entries++
+	c.stats.Bytes += int64(len(audio))
+
+	for c.maxBytes > 0 && c.stats.Bytes > c.maxBytes {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+	return nil
+}
+
+// remove drops `el` from the cache, the caller must hold the lock.
+func (c *MemoryCache) remove(el *list.Element) {
+	e := c.order.Remove(el).(*memoryEntry)
+	delete(c.entries, e.key)
+	c.stats.Entries--
+	c.stats.Bytes -= int64(len(e.audio))
+}
+
+// Stats implements Cache.
+func (c *MemoryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
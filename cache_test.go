@@ -0,0 +1,179 @@
+package azuretexttospeech
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheKey(t *testing.T) {
+	k := CacheKey("<speak/>", "en-US-JennyNeural", AudioRIFF16Bit16kHzMonoPCM, "https://eastus.tts.speech.microsoft.com")
+	assert.Equal(t, 64, len(k))
+	assert.Equal(t, k, CacheKey("<speak/>", "en-US-JennyNeural", AudioRIFF16Bit16kHzMonoPCM, "https://eastus.tts.speech.microsoft.com"))
+	assert.NotEqual(t, k, CacheKey("<speak/>", "en-US-JennyNeural", AudioRAW16Bit16kHzMonoMulaw, "https://eastus.tts.speech.microsoft.com"))
+	assert.NotEqual(t, k, CacheKey("<speak/>", "en-US-JennyNeural", AudioRIFF16Bit16kHzMonoPCM, "https://westus.tts.speech.microsoft.com"))
+	assert.NotEqual(t, CacheKey("ab", "c", 0, ""), CacheKey("a", "bc", 0, ""), "fields are delimited")
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(10, 0)
+	assert.NoError(t, c.Set("a", []byte("1234")))
+	assert.NoError(t, c.Set("b", []byte("1234")))
+	_, ok := c.Get("a") // a is now the most recently used.
+	assert.True(t, ok)
+	assert.NoError(t, c.Set("c", []byte("1234")))
+
+	_, ok = c.Get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+	b, ok := c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1234"), b)
+	assert.NoError(t, c.Set("huge", make([]byte, 11)))
+	_, ok = c.Get("huge")
+	assert.False(t, ok, "entries larger than the cache are not stored")
+
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2, Evictions: 1, Entries: 2, Bytes: 8}, c.Stats())
+	assert.Equal(t, 0.5, c.Stats().HitRatio())
+
+	c = NewMemoryCache(0, time.Millisecond)
+	assert.NoError(t, c.Set("a", []byte("1234")))
+	time.Sleep(5 * time.Millisecond)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "expired")
+	assert.Equal(t, 0, c.Stats().Entries)
+
+	// modifying the audio given to or returned by the cache does not alter the entry.
+	audio := []byte("1234")
+	assert.NoError(t, c.Set("b", audio))
+	audio[0] = 'x'
+	b, _ = c.Get("b")
+	b[1] = 'x'
+	b, _ = c.Get("b")
+	assert.Equal(t, []byte("1234"), b)
+}
+
+func TestDiskCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "azuretts-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := NewDiskCache(dir, 0, 0)
+	assert.NoError(t, err)
+	audio := bytes.Repeat([]byte{1, 2, 3}, 100)
+	assert.NoError(t, c.Set("abcd", audio))
+	assert.Error(t, c.Set("../escape", audio))
+
+	b, ok := c.Get("abcd")
+	assert.True(t, ok)
+	assert.Equal(t, audio, b)
+
+	// entries survive a restart.
+	c, err = NewDiskCache(dir, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.Stats().Entries)
+	b, ok = c.Get("abcd")
+	assert.True(t, ok)
+	assert.Equal(t, audio, b)
+
+	// corrupt entries are discarded.
+	path := filepath.Join(dir, "ab", "abcd.tts")
+	raw, _ := ioutil.ReadFile(path)
+	raw[len(raw)-1] ^= 0xff
+	assert.NoError(t, ioutil.WriteFile(path, raw, 0644))
+	_, ok = c.Get("abcd")
+	assert.False(t, ok)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Evictions: 1}, c.Stats())
+
+	// an entry replaced since it was read is not discarded.
+	assert.NoError(t, c.Set("abcd", audio))
+	stale, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Set("abcd", audio))
+	c.discard("abcd", stale)
+	b, ok = c.Get("abcd")
+	assert.True(t, ok)
+	assert.Equal(t, audio, b)
+
+	// no temporary files are left behind.
+	files, _ := filepath.Glob(filepath.Join(dir, "*", "*.tmp"))
+	assert.Empty(t, files)
+
+	// temporary files of interrupted writes are removed once they are old enough not to be in progress.
+	old, recent := filepath.Join(dir, "ab", "abcd.1.tmp"), filepath.Join(dir, "ab", "abcd.2.tmp")
+	assert.NoError(t, ioutil.WriteFile(old, audio, 0644))
+	assert.NoError(t, ioutil.WriteFile(recent, audio, 0644))
+	past := time.Now().Add(-2 * diskCacheTempAge)
+	assert.NoError(t, os.Chtimes(old, past, past))
+	c, err = NewDiskCache(dir, 0, 0)
+	assert.NoError(t, err)
+	files, _ = filepath.Glob(filepath.Join(dir, "*", "*.tmp"))
+	assert.Equal(t, []string{recent}, files)
+	assert.Equal(t, 1, c.Stats().Entries)
+}
+
+func TestDiskCacheLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "azuretts-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	entry := int64(diskCacheHeaderSize + 100)
+	c, err := NewDiskCache(dir, 2*entry, 0)
+	assert.NoError(t, err)
+	for _, k := range []string{"k1", "k2", "k3"} {
+		assert.NoError(t, c.Set(k, make([]byte, 100)))
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, ok := c.Get("k1")
+	assert.False(t, ok, "oldest entry is evicted")
+	assert.Equal(t, 2, c.Stats().Entries)
+	assert.Equal(t, 2*entry, c.Stats().Bytes)
+
+	c, err = NewDiskCache(dir, 0, time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, ok = c.Get("k2")
+	assert.False(t, ok, "expired")
+}
+
+func TestSynthesizeCache(t *testing.T) {
+	a := AudioRIFF16Bit16kHzMonoPCM
+	tone := toneFixture(t, a)
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(tone)
+	}))
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+		Cache:           NewMemoryCache(0, 0),
+	}
+	first, err := az.SynthesizeResultWithContext(context.Background(), "Press one", LocaleEnUS, GenderFemale, a)
+	assert.NoError(t, err)
+	assert.False(t, first.Cached)
+
+	second, err := az.SynthesizeResultWithContext(context.Background(), "Press one", LocaleEnUS, GenderFemale, a)
+	assert.NoError(t, err)
+	assert.True(t, second.Cached)
+	assert.Equal(t, 0, second.BilledCharacters)
+	assert.Equal(t, first.Audio, second.Audio)
+	assert.Equal(t, time.Second, second.Duration)
+	assert.Equal(t, 1, requests)
+
+	_, err = az.SynthesizeResultWithContext(context.Background(), "Press two", LocaleEnUS, GenderFemale, a)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 2, Entries: 2, Bytes: int64(2 * len(tone))}, az.Cache.Stats())
+}
@@ -0,0 +1,127 @@
+package azuretexttospeech
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// CheckpointEntry records the outcome of the latest run of a BatchJob.
+type CheckpointEntry struct {
+	Key              string    `json:"key"`
+	Hash             string    `json:"hash"`                 // JobHash of the job, detecting edits to the job.
+	AudioHash        string    `json:"audio_hash,omitempty"` // SHA-256 of the audio, empty when the job failed.
+	BilledCharacters int       `json:"billed_characters,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	Time             time.Time `json:"time"`
+}
+
+// Checkpoint records the completed jobs of a batch within a file, so that rerunning the batch after an interruption
+// skips the jobs that finished and have not changed since, see BatchOptions.Checkpoint. The file holds a JSON encoded
+// CheckpointEntry per line and is only appended to, each entry superseding the earlier entries of its key.
+type Checkpoint struct {
+	mu      sync.Mutex
+	f       *os.File
+	entries map[string]CheckpointEntry
+}
+
+// OpenCheckpoint opens the checkpoint file at `path`, creating it when missing. A truncated final line, as left by a
+// process dying mid-write, is ignored.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("unable to read checkpoint, %v", err)
+	}
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		// drop the truncated line, so that the entries appended next remain readable.
+		b = b[:bytes.LastIndexByte(b, '\n')+1]
+		if err := os.Truncate(path, int64(len(b))); err != nil {
+			return nil, fmt.Errorf("unable to repair checkpoint, %v", err)
+		}
+	}
+
+	c := &Checkpoint{entries: map[string]CheckpointEntry{}}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; s.Scan(); line++ {
+		var e CheckpointEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("unable to read checkpoint line %d, %v", line, err)
+		}
+		c.entries[e.Key] = e
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read checkpoint, %v", err)
+	}
+
+	if c.f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return nil, fmt.Errorf("unable to open checkpoint, %v", err)
+	}
+	return c, nil
+}
+
+// Completed returns true when `job` finished successfully in an earlier run and has not changed since.
+func (c *Checkpoint) Completed(job BatchJob) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[job.Key]
+	return ok && e.Error == "" && e.Hash == JobHash(job)
+}
+
+// Entry returns the latest entry recorded for `key`.
+func (c *Checkpoint) Entry(key string) (CheckpointEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+// Record appends the outcome of `job` to the checkpoint. The file is synced before returning, so that a recorded job
+// is never synthesized again.
+func (c *Checkpoint) Record(job BatchJob, result *SynthesisResult, jobErr error) error {
+	e := CheckpointEntry{Key: job.Key, Hash: JobHash(job), Time: time.Now().UTC()}
+	if jobErr != nil {
+		e.Error = jobErr.Error()
+	} else {
+		e.BilledCharacters = result.BilledCharacters
+		if result.Audio != nil {
+			e.AudioHash = fmt.Sprintf("sha256:%x", sha256.Sum256(result.Audio))
+		}
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("unable to write checkpoint, %v", err)
+	}
+	if err := c.f.Sync(); err != nil {
+		return fmt.Errorf("unable to sync checkpoint, %v", err)
+	}
+	c.entries[e.Key] = e
+	return nil
+}
+
+// Close closes the checkpoint file.
+func (c *Checkpoint) Close() error {
+	return c.f.Close()
+}
+
+// JobHash returns the content hash of `job`, covering everything that affects its audio but not its key.
+func JobHash(job BatchJob) string {
+	h := sha256.New()
+	for _, s := range []string{job.Text, job.SSML, job.Voice, job.Locale.String(), job.Gender.String(), job.AudioOutput.String()} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
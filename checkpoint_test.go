@@ -0,0 +1,102 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "azuretts-checkpoint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "batch.checkpoint")
+
+	job := BatchJob{Key: "1", Text: "hello", Voice: "en-US-JennyNeural", AudioOutput: AudioRIFF8Bit8kHzMonoPCM}
+	c, err := OpenCheckpoint(path)
+	assert.NoError(t, err)
+	assert.False(t, c.Completed(job))
+	assert.NoError(t, c.Record(job, nil, assert.AnError))
+	assert.False(t, c.Completed(job), "failures are retried")
+	assert.NoError(t, c.Record(job, &SynthesisResult{Audio: []byte("audio"), BilledCharacters: 5}, nil))
+	assert.True(t, c.Completed(job))
+	assert.NoError(t, c.Close())
+
+	// a process dying mid-write leaves a truncated line behind.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	f.Write([]byte(`{"key":"2","ha`))
+	f.Close()
+
+	c, err = OpenCheckpoint(path)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.True(t, c.Completed(job))
+	e, ok := c.Entry("1")
+	assert.True(t, ok)
+	assert.Equal(t, 5, e.BilledCharacters)
+	assert.Equal(t, "sha256:6ed8919ce20490a5e3ad8630a4fab69475297abd07db73918dd5f36fcfaeb11b", e.AudioHash)
+	_, ok = c.Entry("2")
+	assert.False(t, ok)
+
+	edited := job
+	edited.Text = "hello again"
+	assert.False(t, c.Completed(edited), "edited jobs are synthesized again")
+	assert.NotEqual(t, JobHash(job), JobHash(edited))
+	renamed := job
+	renamed.Key = "renamed"
+	assert.Equal(t, JobHash(job), JobHash(renamed))
+}
+
+func TestSynthesizeBatchCheckpoint(t *testing.T) {
+	var peak int32
+	ts := batchServer(riffFixture(wav.FormatMuLaw, 8000, 8, 4096), &peak)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+	}
+	dir, err := ioutil.TempDir("", "azuretts-checkpoint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "batch.checkpoint")
+
+	jobs := []BatchJob{
+		{Key: "1", Text: "one", Voice: "en-US-JennyNeural", AudioOutput: AudioRIFF8Bit8kHzMonoPCM},
+		{Key: "2", Text: "fail", Voice: "en-US-JennyNeural", AudioOutput: AudioRIFF8Bit8kHzMonoPCM},
+		{Key: "3", Text: "three", Voice: "en-US-JennyNeural", AudioOutput: AudioRIFF8Bit8kHzMonoPCM},
+	}
+	c, err := OpenCheckpoint(path)
+	assert.NoError(t, err)
+	summary, err := az.SynthesizeBatch(context.Background(), jobs, BatchOptions{Rate: -1, Checkpoint: c})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, summary.Succeeded)
+	assert.Equal(t, 1, summary.Failed)
+	assert.NoError(t, c.Close())
+
+	// the rerun retries the failure and the edited job only.
+	jobs[1].Text = "two"
+	jobs[2].Text = "three, edited"
+	c, err = OpenCheckpoint(path)
+	assert.NoError(t, err)
+	defer c.Close()
+	var progress []BatchProgress
+	summary, err = az.SynthesizeBatch(context.Background(), jobs, BatchOptions{
+		Rate:       -1,
+		Checkpoint: c,
+		Progress:   func(p BatchProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Skipped)
+	assert.Equal(t, 2, summary.Succeeded)
+	assert.True(t, summary.Results[0].Skipped)
+	assert.Equal(t, len("two")+len("three, edited"), summary.BilledCharacters)
+	assert.Equal(t, 3, len(progress))
+	assert.Equal(t, 3, progress[2].Completed)
+}
@@ -0,0 +1,79 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"sync"
+)
+
+// inflightGroup coalesces identical concurrent synthesis requests, so that a burst of callers asking for the same
+// audio is served by, and billed for, a single upstream request.
+//
+// The upstream request is not bound to the context of the caller that started it. It runs until it completes or every
+// caller waiting on it has given up, so that a cancelled leader does not fail the requests of its followers.
+type inflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+type inflightCall struct {
+	done    chan struct{}
+	result  *SynthesisResult
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do returns the result of `fn` for `key`, starting it unless a call for `key` is already in flight. Every caller
+// receives its own copy of the result and its audio, so that PostProcessors modifying one in place do not alter the
+// others. Callers joining an existing call receive a copy marked as Coalesced, with no characters billed.
+func (g *inflightGroup) do(ctx context.Context, key string, fn func(context.Context) (*SynthesisResult, error)) (*SynthesisResult, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*inflightCall{}
+	}
+	c, joined := g.calls[key]
+	if !joined {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &inflightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go func() {
+			result, err := fn(callCtx)
+			g.mu.Lock()
+			c.result, c.err = result, err
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			cancel()
+			close(c.done)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		if c.err != nil {
+			return nil, c.err
+		}
+		r := *c.result
+		r.Audio = append([]byte(nil), c.result.Audio...)
+		if joined {
+			r.Coalesced = true
+			r.BilledCharacters = 0
+		}
+		return &r, nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		c.waiters--
+		if c.waiters == 0 {
+			// nobody is left waiting, abandon the upstream request; later callers start afresh.
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		return nil, ctx.Err()
+	}
+}
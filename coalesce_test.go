@@ -0,0 +1,184 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingServer returns a server answering with `audio` once `release` is closed, counting the requests received.
+func blockingServer(audio []byte, release chan struct{}, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		// the server only notices a client going away once the request body has been read.
+		ioutil.ReadAll(r.Body)
+		select {
+		case <-release:
+			w.Write(audio)
+		case <-r.Context().Done():
+		}
+	}))
+}
+
+// waitForWaiters blocks until `n` callers wait on the single in-flight call of `az`.
+func waitForWaiters(az *AzureCSTextToSpeech, n int) {
+	for {
+		az.inflight.mu.Lock()
+		waiters := 0
+		for _, c := range az.inflight.calls {
+			waiters += c.waiters
+		}
+		az.inflight.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	a := AudioRIFF16Bit16kHzMonoPCM
+	release := make(chan struct{})
+	var requests int32
+	ts := blockingServer(toneFixture(t, a), release, &requests)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+		Coalesce:        true,
+	}
+
+	const callers = 50
+	results := make([]*SynthesisResult, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := az.SynthesizeResultWithContext(context.Background(), "Attention please", LocaleEnUS, GenderFemale, a)
+			assert.NoError(t, err)
+			results[i] = r
+		}(i)
+	}
+	waitForWaiters(az, callers)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), requests)
+	billed, coalesced := 0, 0
+	for _, r := range results {
+		billed += r.BilledCharacters
+		if r.Coalesced {
+			coalesced++
+		}
+	}
+	assert.Equal(t, len("Attention please"), billed, "billed once")
+	assert.Equal(t, callers-1, coalesced)
+	assert.Empty(t, az.inflight.calls)
+}
+
+func TestCoalescePostProcessors(t *testing.T) {
+	a := AudioRIFF16Bit16kHzMonoPCM
+	tone := toneFixture(t, a)
+	release := make(chan struct{})
+	var requests int32
+	ts := blockingServer(tone, release, &requests)
+	defer ts.Close()
+
+	// invert the audio in place, applying it twice to shared audio would restore it.
+	invert := func(r *SynthesisResult) (*SynthesisResult, error) {
+		for i := range r.Audio {
+			r.Audio[i] ^= 0xff
+		}
+		return r, nil
+	}
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+		Coalesce:        true,
+		PostProcessors:  []PostProcessor{invert},
+	}
+
+	const callers = 2
+	results := make([]*SynthesisResult, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := az.SynthesizeResultWithContext(context.Background(), "Attention please", LocaleEnUS, GenderFemale, a)
+			assert.NoError(t, err)
+			results[i] = r
+		}(i)
+	}
+	waitForWaiters(az, callers)
+	close(release)
+	wg.Wait()
+
+	inverted := append([]byte(nil), tone...)
+	invert(&SynthesisResult{Audio: inverted})
+	for _, r := range results {
+		assert.Equal(t, inverted, r.Audio)
+	}
+	assert.Equal(t, int32(1), requests)
+}
+
+func TestCoalesceCancel(t *testing.T) {
+	a := AudioRIFF16Bit16kHzMonoPCM
+	release := make(chan struct{})
+	var requests int32
+	ts := blockingServer(toneFixture(t, a), release, &requests)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+		Coalesce:        true,
+	}
+	synthesize := func(ctx context.Context) (*SynthesisResult, error) {
+		return az.SynthesizeResultWithContext(ctx, "Attention please", LocaleEnUS, GenderFemale, a)
+	}
+
+	// the leader gives up, the follower still receives the audio.
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := synthesize(leaderCtx)
+		leaderErr <- err
+	}()
+	waitForWaiters(az, 1)
+	follower := make(chan *SynthesisResult)
+	go func() {
+		r, err := synthesize(context.Background())
+		assert.NoError(t, err)
+		follower <- r
+	}()
+	waitForWaiters(az, 2)
+
+	cancelLeader()
+	assert.Equal(t, context.Canceled, <-leaderErr)
+	close(release)
+	r := <-follower
+	assert.True(t, r.Coalesced)
+	assert.Equal(t, time.Second, r.Duration)
+	assert.Equal(t, int32(1), requests)
+
+	// when every caller gives up the upstream request is abandoned.
+	blocked := make(chan struct{})
+	ts2 := blockingServer(nil, blocked, &requests)
+	defer ts2.Close()
+	az.textToSpeechURL = ts2.URL
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := synthesize(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Empty(t, az.inflight.calls)
+}
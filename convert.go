@@ -0,0 +1,55 @@
+package azuretexttospeech
+
+import (
+	"fmt"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+)
+
+// wavFormat returns the RIFF/WAVE layout of the PCM and G.711 audio outputs.
+func wavFormat(f AudioFormat) (wav.Format, bool) {
+	var tag uint16
+	switch f.Codec {
+	case CodecPCM:
+		tag = wav.FormatPCM
+	case CodecMuLaw:
+		tag = wav.FormatMuLaw
+	case CodecALaw:
+		tag = wav.FormatALaw
+	default:
+		return wav.Format{}, false
+	}
+	return wav.Format{Tag: tag, Channels: f.Channels, SampleRate: f.SampleRate, BitsPerSample: f.BitDepth}, true
+}
+
+// ConvertContainer converts `audio` between the `riff-*` and `raw-*` variants of the same encoding, e.g. from
+// AudioRIFF24khz16bitMonoPcm to AudioRAW24khz16bitMonoPcm. The samples are left untouched.
+func ConvertContainer(audio []byte, from, to AudioOutput) ([]byte, error) {
+	src, dst := from.Format(), to.Format()
+	wf, ok := wavFormat(src)
+	if !ok || src.Codec != dst.Codec || src.SampleRate != dst.SampleRate || src.BitDepth != dst.BitDepth || src.Channels != dst.Channels {
+		return nil, fmt.Errorf("unable to convert %s to %s, the encodings differ", from, to)
+	}
+
+	var raw []byte
+	switch src.Container {
+	case ContainerRIFF:
+		f, err := wav.Decode(audio)
+		if err != nil {
+			return nil, err
+		}
+		raw = f.Data
+	case ContainerRaw:
+		raw = audio
+	default:
+		return nil, fmt.Errorf("unable to convert %s, unsupported container %s", from, src.Container)
+	}
+
+	switch dst.Container {
+	case ContainerRIFF:
+		return wav.Encode(wf, raw), nil
+	case ContainerRaw:
+		return raw, nil
+	}
+	return nil, fmt.Errorf("unable to convert to %s, unsupported container %s", to, dst.Container)
+}
@@ -0,0 +1,28 @@
+package azuretexttospeech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertContainer(t *testing.T) {
+	riff := riffFixture(1, 24000, 16, 480)
+
+	raw, err := ConvertContainer(riff, AudioRIFF24khz16bitMonoPcm, AudioRAW24khz16bitMonoPcm)
+	assert.NoError(t, err)
+	assert.Equal(t, 480, len(raw))
+
+	back, err := ConvertContainer(raw, AudioRAW24khz16bitMonoPcm, AudioRIFF24khz16bitMonoPcm)
+	assert.NoError(t, err)
+	assert.Equal(t, riff, back)
+
+	alaw, err := ConvertContainer(make([]byte, 80), AudioRAW8khz8bitMonoALaw, AudioRIFF8khz8bitMonoALaw)
+	assert.NoError(t, err)
+	assert.NoError(t, validateAudio(alaw, "", AudioRIFF8khz8bitMonoALaw))
+
+	_, err = ConvertContainer(riff, AudioRIFF24khz16bitMonoPcm, AudioRAW16Bit16kHzMonoMulaw)
+	assert.Error(t, err, "sample rates differ")
+	_, err = ConvertContainer(riff, AudioRIFF24khz16bitMonoPcm, Audio24khz48kbitrateMonoMp3)
+	assert.Error(t, err, "encodings differ")
+}
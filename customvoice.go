@@ -0,0 +1,112 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// CustomVoice describes a Custom Neural Voice deployment. Custom voices are not returned by the voices/list API,
+// and are served from a dedicated endpoint that requires the `deploymentId` query parameter.
+// See: https://docs.microsoft.com/en-us/azure/cognitive-services/speech-service/how-to-deploy-and-use-endpoint
+type CustomVoice struct {
+	Name         string // voice name as shown in Speech Studio, e.g. "ContosoBrandNeural".
+	Locale       Locale
+	Gender       Gender
+	DeploymentID string // endpoint/deployment ID of the published voice model.
+}
+
+// voice captures everything required to render a single synthesis request.
+type voice struct {
+	name     string
+	locale   Locale
+	gender   Gender
+	endpoint string
+	custom   bool // a Custom Neural Voice, billed at its own rate.
+}
+
+// RegisterCustomVoice makes the Custom Neural Voice `v` available to SynthesizeVoiceWithContext. Registering a voice with
+// a name that is already registered replaces the previous deployment.
+func (az *AzureCSTextToSpeech) RegisterCustomVoice(v CustomVoice) error {
+	if v.Name == "" {
+		return fmt.Errorf("custom voice requires a name")
+	}
+	if v.DeploymentID == "" {
+		return fmt.Errorf("custom voice %s requires a deployment ID", v.Name)
+	}
+	if !v.Locale.IsALocale() {
+		return fmt.Errorf("custom voice %s has an unsupported locale %s", v.Name, v.Locale)
+	}
+
+	az.mu.Lock()
+	defer az.mu.Unlock()
+	if az.customVoices == nil {
+		az.customVoices = make(map[string]CustomVoice)
+	}
+	az.customVoices[v.Name] = v
+	return nil
+}
+
+// CustomVoices returns the registered Custom Neural Voices sorted by name.
+func (az *AzureCSTextToSpeech) CustomVoices() []CustomVoice {
+	az.mu.RLock()
+	defer az.mu.RUnlock()
+
+	voices := make([]CustomVoice, 0, len(az.customVoices))
+	for _, v := range az.customVoices {
+		voices = append(voices, v)
+	}
+	sort.Slice(voices, func(i, j int) bool { return voices[i].Name < voices[j].Name })
+	return voices
+}
+
+// SynthesizeVoiceWithContext returns a bytestream of the rendered text-to-speech using the voice called `voiceName`.
+// The name is resolved against the registered custom voices first and then the stock voices within RegionVoiceMap,
+// allowing both to be used side by side.
+func (az *AzureCSTextToSpeech) SynthesizeVoiceWithContext(ctx context.Context, speechText string, voiceName string, audioOutput AudioOutput) ([]byte, error) {
+	result, err := az.SynthesizeVoiceResultWithContext(ctx, speechText, voiceName, audioOutput)
+	if err != nil {
+		return nil, err
+	}
+	return result.Audio, nil
+}
+
+// SynthesizeVoiceResultWithContext behaves as SynthesizeVoiceWithContext, but returns the audio along with the metadata of the request.
+func (az *AzureCSTextToSpeech) SynthesizeVoiceResultWithContext(ctx context.Context, speechText string, voiceName string, audioOutput AudioOutput) (*SynthesisResult, error) {
+	v, err := az.resolveVoice(voiceName)
+	if err != nil {
+		return nil, err
+	}
+	return az.synthesize(ctx, v, speechText, voiceXML(speechText, v.name, v.locale, v.gender), audioOutput)
+}
+
+// SynthesizeVoice directs to SynthesizeVoiceWithContext. A new context.Withtimeout is created with the timeout as defined by synthesizeActionTimeout
+func (az *AzureCSTextToSpeech) SynthesizeVoice(speechText string, voiceName string, audioOutput AudioOutput) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), synthesizeActionTimeout)
+	defer cancel()
+	return az.SynthesizeVoiceWithContext(ctx, speechText, voiceName, audioOutput)
+}
+
+// resolveVoice looks up `name` within the custom voices, falling back to the stock voices of RegionVoiceMap.
+func (az *AzureCSTextToSpeech) resolveVoice(name string) (voice, error) {
+	az.mu.RLock()
+	cv, ok := az.customVoices[name]
+	az.mu.RUnlock()
+	if ok {
+		return voice{
+			name:     cv.Name,
+			locale:   cv.Locale,
+			gender:   cv.Gender,
+			endpoint: az.customVoiceURL + "?deploymentId=" + url.QueryEscape(cv.DeploymentID),
+			custom:   true,
+		}, nil
+	}
+
+	for k, shortName := range az.RegionVoiceMap {
+		if shortName == name {
+			return voice{name: shortName, locale: k.Locale, gender: k.Gender, endpoint: az.textToSpeechURL}, nil
+		}
+	}
+	return voice{}, fmt.Errorf("unable to locate voice %s in custom voices or RegionVoiceMap", name)
+}
@@ -0,0 +1,67 @@
+package azuretexttospeech
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterCustomVoice(t *testing.T) {
+	az := &AzureCSTextToSpeech{}
+
+	assert.Error(t, az.RegisterCustomVoice(CustomVoice{DeploymentID: "SYS64738"}), "name is required")
+	assert.Error(t, az.RegisterCustomVoice(CustomVoice{Name: "ContosoNeural"}), "deployment ID is required")
+	assert.Error(t, az.RegisterCustomVoice(CustomVoice{Name: "ContosoNeural", DeploymentID: "SYS64738", Locale: Locale(-1)}))
+
+	assert.NoError(t, az.RegisterCustomVoice(CustomVoice{Name: "ZetaNeural", Locale: LocaleEnGB, DeploymentID: "SYS2064"}))
+	assert.NoError(t, az.RegisterCustomVoice(CustomVoice{Name: "ContosoNeural", Locale: LocaleEnUS, DeploymentID: "SYS64738"}))
+
+	voices := az.CustomVoices()
+	assert.Equal(t, 2, len(voices))
+	assert.Equal(t, "ContosoNeural", voices[0].Name)
+}
+
+func TestSynthesizeVoice(t *testing.T) {
+	var gotPath, gotQuery, gotBody string
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			gotQuery = r.URL.Query().Get("deploymentId")
+			b, _ := ioutil.ReadAll(r.Body)
+			gotBody = string(b)
+			w.Write(audio)
+		}),
+	)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		accessToken:     "SYS49152",
+		textToSpeechURL: ts.URL + "/stock",
+		customVoiceURL:  ts.URL + "/custom",
+		RegionVoiceMap: map[supportedVoices]string{
+			{GenderMale, LocaleDeCH}: "de-CH-Karsten",
+		},
+	}
+	assert.NoError(t, az.RegisterCustomVoice(CustomVoice{Name: "ContosoNeural", Locale: LocaleEnUS, Gender: GenderFemale, DeploymentID: "SYS 64738"}))
+
+	payload, err := az.SynthesizeVoice("hello", "ContosoNeural", AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err)
+	assert.Equal(t, audio, payload)
+	assert.Equal(t, "/custom", gotPath)
+	assert.Equal(t, "SYS 64738", gotQuery)
+	assert.Equal(t, voiceXML("hello", "ContosoNeural", LocaleEnUS, GenderFemale), gotBody)
+
+	// stock voices are resolved by their short name against the RegionVoiceMap.
+	_, err = az.SynthesizeVoice("hallo", "de-CH-Karsten", AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err)
+	assert.Equal(t, "/stock", gotPath)
+	assert.Equal(t, "", gotQuery)
+
+	_, err = az.SynthesizeVoice("hallo", "unknown-voice", AudioRIFF8Bit8kHzMonoPCM)
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/pcm"
+	"github.com/jesseward/azuretexttospeech/wav"
+)
+
+// Speaker describes a voice taking part in a Dialogue and its place within the stereo field.
+type Speaker struct {
+	Locale Locale
+	Gender Gender
+	// Voice is the name of a stock or custom voice, resolved as by SynthesizeVoiceWithContext. When set it takes
+	// precedence over Locale and Gender.
+	Voice string
+	Pan   float64 // stereo position from -1, fully left, through 0, centre, to 1, fully right.
+	Gain  float64 // gain in dB applied to every line of the speaker.
+}
+
+// DialogueLine is a single line spoken within a Dialogue.
+type DialogueLine struct {
+	Speaker string // key of the speaker within Dialogue.Speakers.
+	Text    string
+	// Gap is the pause between the end of the previous line and the start of this line. A negative gap overlaps the
+	// lines, e.g. for interruptions.
+	Gap time.Duration
+}
+
+// Dialogue is a conversation between several speakers, rendered by RenderDialogue.
+type Dialogue struct {
+	Speakers map[string]Speaker
+	Lines    []DialogueLine
+	// AudioOutput is the format requested for each line, it must be a PCM, mu-law or A-law output. The rendered
+	// dialogue is 16 bit stereo PCM at the sample rate of this output.
+	AudioOutput AudioOutput
+}
+
+// RenderDialogue synthesizes each line of `d` in turn and mixes the lines into a stereo RIFF/WAVE file, placing every
+// speaker at their stereo position.
+func (az *AzureCSTextToSpeech) RenderDialogue(ctx context.Context, d Dialogue) ([]byte, error) {
+	f := d.AudioOutput.Format()
+	if _, ok := wavFormat(f); !ok {
+		return nil, fmt.Errorf("unable to render dialogue as %s, a PCM, mu-law or A-law output is required", d.AudioOutput)
+	}
+	for i, line := range d.Lines {
+		if _, ok := d.Speakers[line.Speaker]; !ok {
+			return nil, fmt.Errorf("line %d, unknown speaker %q", i, line.Speaker)
+		}
+	}
+
+	var mix []float64 // interleaved stereo.
+	cursor := 0       // frame at which the previous line ended.
+	for i, line := range d.Lines {
+		s := d.Speakers[line.Speaker]
+
+		var result *SynthesisResult
+		var err error
+		if s.Voice != "" {
+			result, err = az.SynthesizeVoiceResultWithContext(ctx, line.Text, s.Voice, d.AudioOutput)
+		} else {
+			result, err = az.SynthesizeResultWithContext(ctx, line.Text, s.Locale, s.Gender, d.AudioOutput)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d, %v", i, err)
+		}
+		samples, wf, err := decodeSamples(result.Audio, d.AudioOutput)
+		if err != nil {
+			return nil, fmt.Errorf("line %d, %v", i, err)
+		}
+		samples = pcm.Remix(samples, wf.Channels, 1)
+		pcm.Gain(samples, s.Gain)
+
+		start := cursor + toFrames(line.Gap, f.SampleRate)
+		if start < 0 {
+			start = 0
+		}
+		end := start + len(samples)
+		if 2*end > len(mix) {
+			mix = append(mix, make([]float64, 2*end-len(mix))...)
+		}
+		pcm.Mix(mix[2*start:], pcm.Pan(samples, s.Pan))
+		cursor = end
+	}
+
+	w := wav.New(wav.Format{Tag: wav.FormatPCM, Channels: 2, SampleRate: f.SampleRate, BitsPerSample: 16}, nil)
+	if err := w.SetSamples(mix); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
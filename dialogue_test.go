@@ -0,0 +1,83 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderDialogue(t *testing.T) {
+	a := AudioRIFF16Bit16kHzMonoPCM
+	tone := toneFixture(t, a)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tone)
+	}))
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap: map[supportedVoices]string{
+			{GenderFemale, LocaleEnUS}: "en-US-JennyNeural",
+			{GenderMale, LocaleEnUS}:   "en-US-GuyNeural",
+		},
+		textToSpeechURL: ts.URL,
+	}
+	d := Dialogue{
+		Speakers: map[string]Speaker{
+			"host":  {Locale: LocaleEnUS, Gender: GenderFemale, Pan: -1},
+			"guest": {Voice: "en-US-GuyNeural", Pan: 1, Gain: -6},
+		},
+		Lines: []DialogueLine{
+			{Speaker: "host", Text: "Welcome to the show."},
+			{Speaker: "guest", Text: "Thanks for having me.", Gap: 500 * time.Millisecond},
+			{Speaker: "host", Text: "So, tell us...", Gap: -250 * time.Millisecond},
+		},
+		AudioOutput: a,
+	}
+	out, err := az.RenderDialogue(context.Background(), d)
+	assert.NoError(t, err)
+
+	w, err := wav.Decode(out)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, w.Format.Channels)
+	assert.Equal(t, 16000, w.Format.SampleRate)
+	assert.Equal(t, 3250*time.Millisecond, w.Duration())
+
+	samples, _ := w.Samples()
+	// peak returns the peak level of each channel over the 100ms following `at`.
+	peak := func(at time.Duration) (left, right float64) {
+		start := toFrames(at, 16000)
+		for i := start; i < start+1600; i++ {
+			if v := samples[2*i]; v > left {
+				left = v
+			}
+			if v := samples[2*i+1]; v > right {
+				right = v
+			}
+		}
+		return left, right
+	}
+
+	left, right := peak(500 * time.Millisecond)
+	assert.InDelta(t, 0.5, left, 0.01, "host speaks on the left")
+	assert.Equal(t, 0.0, right)
+	left, right = peak(1200 * time.Millisecond)
+	assert.Equal(t, 0.0, left+right, "gap")
+	left, right = peak(2000 * time.Millisecond)
+	assert.Equal(t, 0.0, left)
+	assert.InDelta(t, 0.25, right, 0.01, "guest speaks on the right, 6dB lower")
+	left, right = peak(2300 * time.Millisecond)
+	assert.True(t, left > 0.4 && right > 0.2, "the lines overlap")
+
+	d.Lines = append(d.Lines, DialogueLine{Speaker: "narrator"})
+	_, err = az.RenderDialogue(context.Background(), d)
+	assert.Error(t, err)
+
+	d.AudioOutput = Audio16khz32kbitrateMonoMp3
+	_, err = az.RenderDialogue(context.Background(), d)
+	assert.Error(t, err)
+}
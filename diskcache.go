@@ -0,0 +1,287 @@
+package azuretexttospeech
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diskCacheMagic identifies the files written by DiskCache.
+const diskCacheMagic = "AZTTSC01"
+
+// diskCacheHeaderSize is the size of the header preceding the audio of each file: the magic, the creation time in
+// nanoseconds since the Unix epoch and the SHA-256 checksum of the audio.
+const diskCacheHeaderSize = len(diskCacheMagic) + 8 + sha256.Size
+
+// diskCacheExt is the extension of the cache files; temporary files use a different one and are never loaded.
+const diskCacheExt = ".tts"
+
+// diskCacheTempExt is the extension of the temporary files written by Set.
+const diskCacheTempExt = ".tmp"
+
+// diskCacheTempAge is the age beyond which temporary files are considered left behind by a crashed writer and removed
+// when the cache is opened. Younger ones may still be written by another process sharing the directory.
+const diskCacheTempAge = time.Hour
+
+// DiskCache is a Cache persisting audio to files within a directory, allowing entries to survive restarts and to be
+// shared by processes using the same directory. Files are written atomically, carry a checksum so that corrupt
+// entries are detected and discarded, and are evicted least recently used first once the size limit is reached.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*diskEntry
+	stats   CacheStats
+}
+
+type diskEntry struct {
+	size     int64
+	accessed time.Time
+}
+
+// NewDiskCache returns a DiskCache storing up to `maxBytes` within `dir`, with entries expiring after `ttl`. A zero
+// `maxBytes` or `ttl` disables the respective limit. The directory is created when missing, the entries already
+// present are indexed and the temporary files left behind by interrupted writes are removed.
+func NewDiskCache(dir string, maxBytes int64, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create cache directory, %v", err)
+	}
+	c := &DiskCache{dir: dir, maxBytes: maxBytes, ttl: ttl, entries: map[string]*diskEntry{}}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if filepath.Ext(path) == diskCacheTempExt && time.Since(info.ModTime()) > diskCacheTempAge {
+			os.Remove(path)
+			return nil
+		}
+		if filepath.Ext(path) != diskCacheExt {
+			return nil
+		}
+		key := strings.TrimSuffix(filepath.Base(path), diskCacheExt)
+		c.entries[key] = &diskEntry{size: info.Size(), accessed: info.ModTime()}
+		c.stats.Entries++
+		c.stats.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to index cache directory, %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	return c, nil
+}
+
+// path returns the location of the file for `key`, spread across subdirectories named after the first two characters.
+func (c *DiskCache) path(key string) string {
+	sub := "_"
+	if len(key) >= 2 {
+		sub = key[:2]
+	}
+	return filepath.Join(c.dir, sub, key+diskCacheExt)
+}
+
+// Get implements Cache. Expired and corrupt entries are removed and reported as misses. Entries written by other
+// processes sharing the directory are picked up. The file is read and verified without holding the lock, so that
+// lookups do not wait on the disk I/O of one another.
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	if !validCacheKey(key) {
+		c.mu.Lock()
+		c.stats.Misses++
+		c.mu.Unlock()
+		return nil, false
+	}
+	path := c.path(key)
+	b, info, err := readDiskFile(path)
+	if err != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if e, ok := c.entries[key]; ok {
+			// removed by another process.
+			c.stats.Entries--
+			c.stats.Bytes -= e.size
+			delete(c.entries, key)
+		}
+		c.stats.Misses++
+		return nil, false
+	}
+	audio, created, ok := decodeDiskEntry(b)
+	if !ok || (c.ttl > 0 && time.Since(created) > c.ttl) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.discard(key, info)
+		c.stats.Misses++
+		return nil, false
+	}
+
+	// the modification time records the last access, ordering eviction across restarts.
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &diskEntry{size: int64(len(b))}
+		c.entries[key] = e
+		c.stats.Entries++
+		c.stats.Bytes += e.size
+	}
+	e.accessed = now
+	c.stats.Hits++
+	return audio, true
+}
+
+// readDiskFile returns the content of the file at `path` along with its description, identifying the file read.
+func readDiskFile(path string) ([]byte, os.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, info, nil
+}
+
+// discard removes the expired or corrupt entry `key` read from the file described by `info`, unless a Set has replaced
+// the file since it was read. The caller must hold the lock, which Set holds while renaming its file into place.
+func (c *DiskCache) discard(key string, info os.FileInfo) {
+	if current, err := os.Stat(c.path(key)); err == nil && !os.SameFile(info, current) {
+		return
+	}
+	c.remove(key)
+	c.stats.Evictions++
+}
+
+// validCacheKey returns true for keys that are safe to use as file names.
+func validCacheKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, `/\.`)
+}
+
+// Set implements Cache. The entry is written to a temporary file which is renamed into place, so that readers never
+// observe a partial entry.
+func (c *DiskCache) Set(key string, audio []byte) error {
+	if !validCacheKey(key) {
+		return fmt.Errorf("invalid cache key %q", key)
+	}
+	b := encodeDiskEntry(audio, time.Now())
+	if c.maxBytes > 0 && int64(len(b)) > c.maxBytes {
+		return nil
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), key+".*"+diskCacheTempExt)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if e, ok := c.entries[key]; ok {
+		c.stats.Entries--
+		c.stats.Bytes -= e.size
+	}
+	c.entries[key] = &diskEntry{size: int64(len(b)), accessed: time.Now()}
+	c.stats.Entries++
+	c.stats.Bytes += int64(len(b))
+	c.evict()
+	return nil
+}
+
+// evict removes the least recently used entries until the cache fits its size limit, the caller must hold the lock.
+func (c *DiskCache) evict() {
+	if c.maxBytes <= 0 || c.stats.Bytes <= c.maxBytes {
+		return
+	}
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return c.entries[keys[i]].accessed.Before(c.entries[keys[j]].accessed) })
+	for _, k := range keys {
+		if c.stats.Bytes <= c.maxBytes {
+			return
+		}
+		c.remove(k)
+		c.stats.Evictions++
+	}
+}
+
+// remove deletes the entry `key`, the caller must hold the lock.
+func (c *DiskCache) remove(key string) {
+	if e, ok := c.entries[key]; ok {
+		c.stats.Entries--
+		c.stats.Bytes -= e.size
+		delete(c.entries, key)
+	}
+	os.Remove(c.path(key))
+}
+
+// Stats implements Cache.
+func (c *DiskCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func encodeDiskEntry(audio []byte, created time.Time) []byte {
+	b := make([]byte, diskCacheHeaderSize, diskCacheHeaderSize+len(audio))
+	copy(b, diskCacheMagic)
+	binary.BigEndian.PutUint64(b[len(diskCacheMagic):], uint64(created.UnixNano()))
+	sum := sha256.Sum256(audio)
+	copy(b[len(diskCacheMagic)+8:], sum[:])
+	return append(b, audio...)
+}
+
+// decodeDiskEntry returns the audio and creation time of a cache file, or false when the file is corrupt.
+func decodeDiskEntry(b []byte) ([]byte, time.Time, bool) {
+	if len(b) < diskCacheHeaderSize || string(b[:len(diskCacheMagic)]) != diskCacheMagic {
+		return nil, time.Time{}, false
+	}
+	created := time.Unix(0, int64(binary.BigEndian.Uint64(b[len(diskCacheMagic):])))
+	audio := b[diskCacheHeaderSize:]
+	sum := sha256.Sum256(audio)
+	if !bytes.Equal(sum[:], b[len(diskCacheMagic)+8:diskCacheHeaderSize]) {
+		return nil, time.Time{}, false
+	}
+	return audio, created, true
+}
@@ -0,0 +1,169 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Document describes how RenderDocument voices a text document, paragraph by paragraph.
+type Document struct {
+	Voice       string // name of a stock or custom voice, resolved as by SynthesizeVoiceWithContext.
+	AudioOutput AudioOutput
+	Gap         time.Duration // pause inserted between paragraphs.
+	// Store holds the audio of every paragraph between revisions, e.g. a DiskCache. Paragraphs found in the store are
+	// not synthesized again.
+	Store Cache
+	// Batch sets the concurrency and rate of the requests for new and edited paragraphs, see SynthesizeBatch. Its
+	// Output and Checkpoint are not used.
+	Batch BatchOptions
+}
+
+// DocumentParagraph describes a paragraph within the audio of a rendered document.
+type DocumentParagraph struct {
+	Text     string
+	Hash     string        // identifies the paragraph within Document.Store.
+	Reused   bool          // the audio came from Document.Store rather than a new request.
+	Offset   time.Duration // start of the paragraph within the document audio.
+	Duration time.Duration
+}
+
+// DocumentResult is the outcome of RenderDocument.
+type DocumentResult struct {
+	Audio            []byte
+	AudioOutput      AudioOutput
+	Paragraphs       []DocumentParagraph
+	BilledCharacters int // characters billed for the paragraphs synthesized by this revision.
+}
+
+// Synthesized returns the number of paragraphs that were synthesized rather than reused.
+func (r *DocumentResult) Synthesized() int {
+	n := 0
+	for _, p := range r.Paragraphs {
+		if !p.Reused {
+			n++
+		}
+	}
+	return n
+}
+
+// SegmentParagraphs splits `text` into paragraphs separated by blank lines. The whitespace within each paragraph is
+// collapsed, so that reflowing a paragraph does not change it.
+func SegmentParagraphs(text string) []string {
+	var paragraphs []string
+	var words []string
+	flush := func() {
+		if len(words) > 0 {
+			paragraphs = append(paragraphs, strings.Join(words, " "))
+			words = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			flush()
+			continue
+		}
+		words = append(words, fields...)
+	}
+	flush()
+	return paragraphs
+}
+
+// paragraphKey returns the key of the audio of `paragraph` within Document.Store.
+func paragraphKey(voice string, audioOutput AudioOutput, paragraph string) string {
+	h := sha256.New()
+	for _, s := range []string{cacheKeyVersion, "paragraph", voice, audioOutput.String(), paragraph} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RenderDocument voices `text` as described by `doc`. The text is split into paragraphs by SegmentParagraphs, and only
+// the paragraphs missing from the store of `doc`, those new or edited since an earlier revision, are synthesized. The
+// audio of every paragraph is then joined, as by JoinWithSilence, into the audio of the whole document. Paragraphs
+// are synthesized as separate requests, so their MP3 audio does not reference the bit reservoir across the joins.
+func (az *AzureCSTextToSpeech) RenderDocument(ctx context.Context, doc Document, text string) (*DocumentResult, error) {
+	if doc.Store == nil {
+		return nil, fmt.Errorf("document requires a store for the audio of its paragraphs")
+	}
+	if doc.Voice == "" {
+		return nil, fmt.Errorf("document requires a voice")
+	}
+	texts := SegmentParagraphs(text)
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("document has no paragraphs")
+	}
+
+	result := &DocumentResult{AudioOutput: doc.AudioOutput, Paragraphs: make([]DocumentParagraph, len(texts))}
+	segments := make([][]byte, len(texts))
+	var jobs []BatchJob
+	queued := map[string]bool{}
+	for i, t := range texts {
+		key := paragraphKey(doc.Voice, doc.AudioOutput, t)
+		result.Paragraphs[i] = DocumentParagraph{Text: t, Hash: key}
+		if b, ok := doc.Store.Get(key); ok {
+			segments[i] = b
+			result.Paragraphs[i].Reused = true
+			continue
+		}
+		if !queued[key] {
+			queued[key] = true
+			jobs = append(jobs, BatchJob{Key: key, Text: t, Voice: doc.Voice, AudioOutput: doc.AudioOutput})
+		}
+	}
+
+	if len(jobs) > 0 {
+		var mu sync.Mutex
+		synthesized := map[string][]byte{}
+		opts := doc.Batch
+		opts.Checkpoint = nil
+		opts.Output = func(key string, r *SynthesisResult) error {
+			mu.Lock()
+			synthesized[key] = r.Audio
+			mu.Unlock()
+			return doc.Store.Set(key, r.Audio)
+		}
+		summary, err := az.SynthesizeBatch(ctx, jobs, opts)
+		if err != nil {
+			return nil, err
+		}
+		if failed := summary.Errors(); len(failed) > 0 {
+			return nil, fmt.Errorf("unable to synthesize %d of %d paragraphs, %v", len(failed), len(jobs), failed[0].Err)
+		}
+		result.BilledCharacters = summary.BilledCharacters
+		for i, p := range result.Paragraphs {
+			if !p.Reused {
+				segments[i] = synthesized[p.Hash]
+			}
+		}
+	}
+
+	var gap time.Duration
+	if doc.Gap > 0 {
+		silence, err := Silence(doc.Gap, doc.AudioOutput)
+		if err != nil {
+			return nil, err
+		}
+		gap = audioDuration(silence, doc.AudioOutput)
+	}
+	var offset time.Duration
+	for i := range result.Paragraphs {
+		d := audioDuration(segments[i], doc.AudioOutput)
+		result.Paragraphs[i].Offset = offset
+		result.Paragraphs[i].Duration = d
+		offset += d + gap
+	}
+
+	audio, err := JoinWithSilence(doc.Gap, doc.AudioOutput, segments...)
+	if err != nil {
+		return nil, err
+	}
+	result.Audio = audio
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentParagraphs(t *testing.T) {
+	text := "  First paragraph,\nwrapped   over lines.\n\n\n\tSecond.\n \nThird\r\n"
+	assert.Equal(t, []string{"First paragraph, wrapped over lines.", "Second.", "Third"}, SegmentParagraphs(text))
+	assert.Empty(t, SegmentParagraphs(" \n\n "))
+}
+
+func TestRenderDocument(t *testing.T) {
+	a := AudioRIFF16Bit16kHzMonoPCM
+	tone := toneFixture(t, a)
+	var mu sync.Mutex
+	var spoken []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		text, _, _ := parseSSML(string(b))
+		mu.Lock()
+		spoken = append(spoken, text)
+		mu.Unlock()
+		w.Write(tone)
+	}))
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+	}
+	doc := Document{
+		Voice:       "en-US-JennyNeural",
+		AudioOutput: a,
+		Gap:         500 * time.Millisecond,
+		Store:       NewMemoryCache(0, 0),
+		Batch:       BatchOptions{Rate: -1},
+	}
+
+	first, err := az.RenderDocument(context.Background(), doc, "Welcome.\n\nStep one.\n\nStep two.\n\nWelcome.")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(spoken), "repeated paragraphs are synthesized once")
+	assert.Equal(t, 4, first.Synthesized())
+	assert.Equal(t, len("Welcome.Step one.Step two."), first.BilledCharacters)
+	assert.Equal(t, 4*time.Second+3*500*time.Millisecond, audioDuration(first.Audio, a))
+	assert.Equal(t, 3*1500*time.Millisecond, first.Paragraphs[3].Offset)
+	assert.Equal(t, time.Second, first.Paragraphs[3].Duration)
+	assert.Equal(t, first.Paragraphs[0].Hash, first.Paragraphs[3].Hash)
+
+	// reflowing a paragraph keeps its audio, editing one synthesizes it again.
+	spoken = nil
+	second, err := az.RenderDocument(context.Background(), doc, "Welcome.\n\nStep\none.\n\nStep two, revised.\n\nWelcome.")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Step two, revised."}, spoken)
+	assert.Equal(t, 1, second.Synthesized())
+	assert.True(t, second.Paragraphs[1].Reused)
+	assert.False(t, second.Paragraphs[2].Reused)
+	assert.Equal(t, len("Step two, revised."), second.BilledCharacters)
+	assert.Equal(t, len(first.Audio), len(second.Audio))
+
+	// the audio is kept per voice and format.
+	spoken = nil
+	doc.AudioOutput = AudioRIFF8Bit8kHzMonoPCM
+	tone = toneFixture(t, doc.AudioOutput)
+	_, err = az.RenderDocument(context.Background(), doc, "Welcome.")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(spoken))
+
+	_, err = az.RenderDocument(context.Background(), doc, "\n\n")
+	assert.Error(t, err)
+	_, err = az.RenderDocument(context.Background(), Document{Voice: "en-US-JennyNeural"}, "Welcome.")
+	assert.Error(t, err, "a store is required")
+	doc.Voice = "UnknownNeural"
+	_, err = az.RenderDocument(context.Background(), doc, "Something "+strings.Repeat("new ", 3))
+	assert.Error(t, err)
+}
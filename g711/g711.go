@@ -0,0 +1,126 @@
+/*
+Package g711 implements the ITU-T G.711 mu-law and A-law companding codecs used by the `*-mulaw` and `*-alaw` audio
+outputs of the Azure text-to-speech API. Each codec maps a 16 bit linear PCM sample onto a single byte.
+*/
+package g711
+
+const (
+	muLawBias = 0x84
+	muLawClip = 32635
+)
+
+// aLawSegmentEnd holds the upper bound of each of the eight A-law segments, for 13 bit magnitudes.
+var aLawSegmentEnd = [8]int{0x1f, 0x3f, 0x7f, 0xff, 0x1ff, 0x3ff, 0x7ff, 0xfff}
+
+// EncodeMuLaw compands the 16 bit linear sample `s` to mu-law.
+func EncodeMuLaw(s int16) byte {
+	sample := int(s)
+	var sign int
+	if sample < 0 {
+		sign = 0x80
+		sample = -sample
+	}
+	if sample > muLawClip {
+		sample = muLawClip
+	}
+	sample += muLawBias
+
+	exponent := 7
+	for mask := 0x4000; sample&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (sample >> (exponent + 3)) & 0x0f
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+// DecodeMuLaw expands the mu-law byte `u` to a 16 bit linear sample.
+func DecodeMuLaw(u byte) int16 {
+	u = ^u
+	t := (int(u&0x0f) << 3) + muLawBias
+	t <<= (u & 0x70) >> 4
+	if u&0x80 != 0 {
+		return int16(muLawBias - t)
+	}
+	return int16(t - muLawBias)
+}
+
+// EncodeALaw compands the 16 bit linear sample `s` to A-law.
+func EncodeALaw(s int16) byte {
+	sample := int(s) >> 3
+	mask := byte(0xd5)
+	if sample < 0 {
+		mask = 0x55
+		sample = -sample - 1
+	}
+
+	segment := 0
+	for segment < len(aLawSegmentEnd) && sample > aLawSegmentEnd[segment] {
+		segment++
+	}
+	if segment >= len(aLawSegmentEnd) {
+		return 0x7f ^ mask
+	}
+
+	a := byte(segment << 4)
+	if segment < 2 {
+		a |= byte(sample>>1) & 0x0f
+	} else {
+		a |= byte(sample>>uint(segment)) & 0x0f
+	}
+	return a ^ mask
+}
+
+// DecodeALaw expands the A-law byte `a` to a 16 bit linear sample.
+func DecodeALaw(a byte) int16 {
+	a ^= 0x55
+	t := int(a&0x0f) << 4
+	switch segment := (a & 0x70) >> 4; segment {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= segment - 1
+	}
+	if a&0x80 != 0 {
+		return int16(t)
+	}
+	return int16(-t)
+}
+
+// MuLawToLinear expands a mu-law stream to 16 bit linear samples.
+func MuLawToLinear(b []byte) []int16 {
+	out := make([]int16, len(b))
+	for i, u := range b {
+		out[i] = DecodeMuLaw(u)
+	}
+	return out
+}
+
+// LinearToMuLaw compands 16 bit linear samples to a mu-law stream.
+func LinearToMuLaw(samples []int16) []byte {
+	out := make([]byte, len(samples))
+	for i, s := range samples {
+		out[i] = EncodeMuLaw(s)
+	}
+	return out
+}
+
+// ALawToLinear expands an A-law stream to 16 bit linear samples.
+func ALawToLinear(b []byte) []int16 {
+	out := make([]int16, len(b))
+	for i, a := range b {
+		out[i] = DecodeALaw(a)
+	}
+	return out
+}
+
+// LinearToALaw compands 16 bit linear samples to an A-law stream.
+func LinearToALaw(samples []int16) []byte {
+	out := make([]byte, len(samples))
+	for i, s := range samples {
+		out[i] = EncodeALaw(s)
+	}
+	return out
+}
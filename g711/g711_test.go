@@ -0,0 +1,55 @@
+package g711
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuLaw(t *testing.T) {
+	assert.Equal(t, byte(0xff), EncodeMuLaw(0))
+	assert.Equal(t, int16(0), DecodeMuLaw(0xff))
+	assert.Equal(t, int16(-32124), DecodeMuLaw(0x00))
+	assert.Equal(t, int16(32124), DecodeMuLaw(0x80))
+	assert.Equal(t, byte(0x80), EncodeMuLaw(math.MaxInt16))
+	assert.Equal(t, byte(0x00), EncodeMuLaw(math.MinInt16))
+
+	// every code word survives a decode/encode round trip, except for negative zero.
+	for i := 0; i < 256; i++ {
+		if i == 0x7f {
+			continue
+		}
+		assert.Equal(t, byte(i), EncodeMuLaw(DecodeMuLaw(byte(i))), "code 0x%02x", i)
+	}
+}
+
+func TestALaw(t *testing.T) {
+	assert.Equal(t, int16(8), DecodeALaw(0xd5))
+	assert.Equal(t, int16(-8), DecodeALaw(0x55))
+	assert.Equal(t, int16(32256), DecodeALaw(0xaa))
+	assert.Equal(t, byte(0xd5), EncodeALaw(0))
+	assert.Equal(t, byte(0xaa), EncodeALaw(math.MaxInt16))
+
+	for i := 0; i < 256; i++ {
+		assert.Equal(t, byte(i), EncodeALaw(DecodeALaw(byte(i))), "code 0x%02x", i)
+	}
+}
+
+func TestCompandingError(t *testing.T) {
+	// the quantisation error of both codecs is bounded relative to the magnitude of the sample.
+	for s := -32000; s <= 32000; s += 97 {
+		mu := int(DecodeMuLaw(EncodeMuLaw(int16(s))))
+		a := int(DecodeALaw(EncodeALaw(int16(s))))
+		limit := math.Abs(float64(s))/16 + 16
+		assert.True(t, math.Abs(float64(mu-s)) <= limit, "mu-law sample %d decoded as %d", s, mu)
+		assert.True(t, math.Abs(float64(a-s)) <= limit, "A-law sample %d decoded as %d", s, a)
+	}
+}
+
+func TestSlices(t *testing.T) {
+	in := []int16{0, 1000, -1000, 20000}
+	assert.Equal(t, MuLawToLinear(LinearToMuLaw(in)), MuLawToLinear(LinearToMuLaw(MuLawToLinear(LinearToMuLaw(in)))))
+	assert.Equal(t, 4, len(LinearToALaw(in)))
+	assert.Equal(t, 4, len(ALawToLinear(LinearToALaw(in))))
+}
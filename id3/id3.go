@@ -0,0 +1,272 @@
+/*
+Package id3 writes, and reads back, ID3v2.3 tags, the metadata format read by media libraries and players from MP3
+files, including the chapter frames (CHAP and CTOC) of the ID3v2 Chapter Frame Addendum. ID3v2.3 is used rather than
+ID3v2.4 as it remains the most widely supported version.
+*/
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+	"unicode/utf16"
+)
+
+// HeaderSize is the size of the tag header.
+const HeaderSize = 10
+
+// text encodings of ID3v2.3.
+const (
+	encodingLatin1 byte = 0
+	encodingUTF16  byte = 1 // UTF-16 with a byte order mark.
+)
+
+// noOffset marks the byte offsets of a CHAP frame as unused, leaving the times to locate the chapter.
+const noOffset = 0xffffffff
+
+// tocID is the element ID of the table of contents written by Tag.Bytes.
+const tocID = "toc"
+
+// Chapter is a section of the audio, written as a CHAP frame.
+type Chapter struct {
+	ID    string // element ID unique within the tag, e.g. "chp0". Generated from the position when empty.
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// Frame is a raw ID3v2.3 frame.
+type Frame struct {
+	ID   string // four character frame ID, e.g. "TIT2".
+	Data []byte
+}
+
+// Tag holds the frames written by Bytes. Empty fields are omitted.
+type Tag struct {
+	Title    string // TIT2
+	Artist   string // TPE1
+	Album    string // TALB
+	Language string // TLAN, ISO 639-2 code such as "eng". Also used as the language of the comment.
+	Comment  string // COMM
+	Chapters []Chapter
+	Frames   []Frame // additional frames, written after the others.
+}
+
+// TextFrame returns a text information frame, such as TIT2, holding `s`.
+func TextFrame(id, s string) Frame {
+	return Frame{ID: id, Data: encodeText(s)}
+}
+
+// encodeText returns `s` prefixed by its encoding byte. Latin-1 is used when `s` can be represented, and UTF-16
+// otherwise.
+func encodeText(s string) []byte {
+	encoding := encodingLatin1
+	if !isLatin1(s) {
+		encoding = encodingUTF16
+	}
+	return append([]byte{encoding}, encodeString(s, encoding)...)
+}
+
+func isLatin1(s string) bool {
+	for _, r := range s {
+		if r > 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeString returns `s` in `encoding`, without an encoding byte or terminator.
+func encodeString(s string, encoding byte) []byte {
+	if encoding == encodingLatin1 {
+		b := make([]byte, 0, len(s))
+		for _, r := range s {
+			b = append(b, byte(r))
+		}
+		return b
+	}
+	b := []byte{0xff, 0xfe} // little endian byte order mark.
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return b
+}
+
+func terminator(encoding byte) []byte {
+	if encoding == encodingLatin1 {
+		return []byte{0}
+	}
+	return []byte{0, 0}
+}
+
+// commentFrame returns a COMM frame holding `s` in the three letter `language`.
+func commentFrame(language, s string) Frame {
+	encoding := encodingLatin1
+	if !isLatin1(s) {
+		encoding = encodingUTF16
+	}
+	lang := []byte("XXX") // unknown language.
+	if len(language) == 3 {
+		lang = []byte(language)
+	}
+	data := append([]byte{encoding}, lang...)
+	data = append(data, encodeString("", encoding)...) // empty content descriptor.
+	data = append(data, terminator(encoding)...)
+	data = append(data, encodeString(s, encoding)...)
+	return Frame{ID: "COMM", Data: data}
+}
+
+// chapterFrame returns the CHAP frame of `c`, embedding its title.
+func chapterFrame(c Chapter) Frame {
+	data := append([]byte(c.ID), 0)
+	var times [16]byte
+	binary.BigEndian.PutUint32(times[0:], uint32(c.Start/time.Millisecond))
+	binary.BigEndian.PutUint32(times[4:], uint32(c.End/time.Millisecond))
+	binary.BigEndian.PutUint32(times[8:], noOffset)
+	binary.BigEndian.PutUint32(times[12:], noOffset)
+	data = append(data, times[:]...)
+	if c.Title != "" {
+		data = append(data, TextFrame("TIT2", c.Title).bytes()...)
+	}
+	return Frame{ID: "CHAP", Data: data}
+}
+
+// tocFrame returns a top level, ordered CTOC frame listing `chapters`.
+func tocFrame(chapters []Chapter) Frame {
+	data := append([]byte(tocID), 0)
+	data = append(data, 0x03, byte(len(chapters))) // top level and ordered flags, entry count.
+	for _, c := range chapters {
+		data = append(data, c.ID...)
+		data = append(data, 0)
+	}
+	return Frame{ID: "CTOC", Data: data}
+}
+
+// bytes encodes the frame with its header.
+func (f Frame) bytes() []byte {
+	b := make([]byte, 10, 10+len(f.Data))
+	copy(b, f.ID)
+	binary.BigEndian.PutUint32(b[4:], uint32(len(f.Data)))
+	return append(b, f.Data...)
+}
+
+// frames returns the frames of the tag in the order they are written.
+func (t *Tag) frames() ([]Frame, error) {
+	var frames []Frame
+	for _, f := range []struct{ id, s string }{{"TIT2", t.Title}, {"TPE1", t.Artist}, {"TALB", t.Album}, {"TLAN", t.Language}} {
+		if f.s != "" {
+			frames = append(frames, TextFrame(f.id, f.s))
+		}
+	}
+	if t.Comment != "" {
+		frames = append(frames, commentFrame(t.Language, t.Comment))
+	}
+
+	if len(t.Chapters) > 255 {
+		return nil, fmt.Errorf("id3: %d chapters exceed the 255 entries of a table of contents", len(t.Chapters))
+	}
+	chapters := make([]Chapter, len(t.Chapters))
+	for i, c := range t.Chapters {
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("chp%d", i)
+		}
+		if c.End < c.Start {
+			return nil, fmt.Errorf("id3: chapter %q ends before it starts", c.ID)
+		}
+		chapters[i] = c
+	}
+	if len(chapters) > 0 {
+		frames = append(frames, tocFrame(chapters))
+		for _, c := range chapters {
+			frames = append(frames, chapterFrame(c))
+		}
+	}
+
+	for _, f := range t.Frames {
+		if len(f.ID) != 4 {
+			return nil, fmt.Errorf("id3: invalid frame ID %q", f.ID)
+		}
+	}
+	return append(frames, t.Frames...), nil
+}
+
+// Bytes encodes the tag as ID3v2.3, ready to be prepended to MP3 audio.
+func (t *Tag) Bytes() ([]byte, error) {
+	frames, err := t.frames()
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	for _, f := range frames {
+		body.Write(f.bytes())
+	}
+	if body.Len() >= 1<<28 {
+		return nil, fmt.Errorf("id3: tag of %d bytes exceeds the maximum size", body.Len())
+	}
+
+	size := body.Len()
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(header, body.Bytes()...), nil
+}
+
+// ReadFrames decodes the frames of the ID3v2.3 tag at the start of `b`. Padding after the final frame is skipped.
+func ReadFrames(b []byte) ([]Frame, error) {
+	if len(b) < HeaderSize || string(b[0:3]) != "ID3" {
+		return nil, fmt.Errorf("id3: missing tag header")
+	}
+	if b[3] != 3 {
+		return nil, fmt.Errorf("id3: unsupported version 2.%d", b[3])
+	}
+	size := int(b[6]&0x7f)<<21 | int(b[7]&0x7f)<<14 | int(b[8]&0x7f)<<7 | int(b[9]&0x7f)
+	if len(b) < HeaderSize+size {
+		return nil, fmt.Errorf("id3: truncated tag, expected %d bytes", HeaderSize+size)
+	}
+
+	var frames []Frame
+	body := b[HeaderSize : HeaderSize+size]
+	for len(body) >= 10 && body[0] != 0 {
+		n := int(binary.BigEndian.Uint32(body[4:8]))
+		if len(body) < 10+n {
+			return nil, fmt.Errorf("id3: truncated frame %q", body[0:4])
+		}
+		frames = append(frames, Frame{ID: string(body[0:4]), Data: body[10 : 10+n]})
+		body = body[10+n:]
+	}
+	return frames, nil
+}
+
+// Text decodes the string of a text information frame.
+func (f Frame) Text() string {
+	if len(f.Data) == 0 {
+		return ""
+	}
+	b := f.Data[1:]
+	if f.Data[0] == encodingLatin1 {
+		r := make([]rune, 0, len(b))
+		for _, c := range b {
+			if c == 0 {
+				break
+			}
+			r = append(r, rune(c))
+		}
+		return string(r)
+	}
+
+	var u []uint16
+	little := len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe
+	if len(b) >= 2 && (little || b[0] == 0xfe && b[1] == 0xff) {
+		b = b[2:]
+	}
+	for i := 0; i+1 < len(b); i += 2 {
+		v := binary.BigEndian.Uint16(b[i:])
+		if little {
+			v = binary.LittleEndian.Uint16(b[i:])
+		}
+		if v == 0 {
+			break
+		}
+		u = append(u, v)
+	}
+	return string(utf16.Decode(u))
+}
@@ -0,0 +1,59 @@
+package id3
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTag(t *testing.T) {
+	tag := &Tag{
+		Title:    "Welcome",
+		Artist:   "zh-CN-XiaoxiaoNeural",
+		Album:    "语音提示",
+		Language: "chi",
+		Comment:  "sha256:abc",
+		Chapters: []Chapter{
+			{Title: "Intro", End: 1500 * time.Millisecond},
+			{ID: "main", Title: "Menu", Start: 1500 * time.Millisecond, End: 4 * time.Second},
+		},
+		Frames: []Frame{TextFrame("TENC", "azuretts")},
+	}
+	b, err := tag.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, "ID3\x03\x00\x00", string(b[0:6]))
+
+	frames, err := ReadFrames(b)
+	assert.NoError(t, err)
+	ids := []string{}
+	for _, f := range frames {
+		ids = append(ids, f.ID)
+	}
+	assert.Equal(t, []string{"TIT2", "TPE1", "TALB", "TLAN", "COMM", "CTOC", "CHAP", "CHAP", "TENC"}, ids)
+	assert.Equal(t, "Welcome", frames[0].Text())
+	assert.Equal(t, byte(encodingLatin1), frames[0].Data[0])
+	assert.Equal(t, "语音提示", frames[2].Text(), "UTF-16 is used beyond Latin-1")
+	assert.Equal(t, byte(encodingUTF16), frames[2].Data[0])
+	assert.Equal(t, "\x00chi\x00sha256:abc", string(frames[4].Data))
+	assert.Equal(t, "toc\x00\x03\x02chp0\x00main\x00", string(frames[5].Data))
+
+	chap := frames[7].Data
+	assert.Equal(t, "main\x00", string(chap[0:5]))
+	assert.Equal(t, uint32(1500), binary.BigEndian.Uint32(chap[5:]))
+	assert.Equal(t, uint32(4000), binary.BigEndian.Uint32(chap[9:]))
+	assert.Equal(t, uint32(noOffset), binary.BigEndian.Uint32(chap[13:]))
+	sub, err := ReadFrames(append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, byte(len(chap) - 21)}, chap[21:]...))
+	assert.NoError(t, err)
+	assert.Equal(t, "Menu", sub[0].Text(), "chapter titles are embedded TIT2 frames")
+}
+
+func TestTagErrors(t *testing.T) {
+	_, err := (&Tag{Chapters: []Chapter{{Start: time.Second}}}).Bytes()
+	assert.Error(t, err)
+	_, err = (&Tag{Frames: []Frame{{ID: "TX"}}}).Bytes()
+	assert.Error(t, err)
+	_, err = ReadFrames([]byte("ID3\x04\x00\x00\x00\x00\x00\x00"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package azuretexttospeech
+
+import (
+	"github.com/jesseward/azuretexttospeech/pcm"
+)
+
+// DefaultLoudnessTarget is the integrated loudness, in LUFS, recommended by EBU R128 for broadcast.
+const DefaultLoudnessTarget = -23.0
+
+// DefaultMaxTruePeak is the true peak ceiling, in dBTP, recommended by EBU R128.
+const DefaultMaxTruePeak = -1.0
+
+// LoudnessOptions configures loudness normalization.
+type LoudnessOptions struct {
+	Target      float64 // integrated loudness in LUFS, DefaultLoudnessTarget when zero. e.g. -16 for podcasts.
+	MaxTruePeak float64 // true peak ceiling in dBTP, DefaultMaxTruePeak when zero.
+}
+
+func (o LoudnessOptions) target() float64 {
+	if o.Target == 0 {
+		return DefaultLoudnessTarget
+	}
+	return o.Target
+}
+
+func (o LoudnessOptions) maxTruePeak() float64 {
+	if o.MaxTruePeak == 0 {
+		return DefaultMaxTruePeak
+	}
+	return o.MaxTruePeak
+}
+
+// MeasureLoudness returns the integrated loudness and true peak of PCM, mu-law or A-law `audio`, as specified by
+// ITU-R BS.1770-4.
+func MeasureLoudness(audio []byte, audioOutput AudioOutput) (pcm.Loudness, error) {
+	samples, wf, err := decodeSamples(audio, audioOutput)
+	if err != nil {
+		return pcm.Loudness{}, err
+	}
+	return pcm.MeasureLoudness(samples, wf.Channels, wf.SampleRate), nil
+}
+
+// NormalizeLoudness applies a constant gain to PCM, mu-law or A-law `audio` bringing its integrated loudness to the
+// target of `opts`. The gain is reduced when it would push the true peak above the ceiling, leaving the audio quieter
+// than the target rather than distorting it. Silent audio is returned unchanged.
+func NormalizeLoudness(audio []byte, audioOutput AudioOutput, opts LoudnessOptions) ([]byte, error) {
+	w, err := decodeFile(audio, audioOutput)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := w.Samples()
+	if err != nil {
+		return nil, err
+	}
+
+	l := pcm.MeasureLoudness(samples, w.Format.Channels, w.Format.SampleRate)
+	gain := pcm.NormalizationGain(l, opts.target(), opts.maxTruePeak())
+	if gain == 0 {
+		return audio, nil
+	}
+	pcm.Gain(samples, gain)
+
+	out := *w
+	if err := out.SetSamples(samples); err != nil {
+		return nil, err
+	}
+	return encodeFile(&out, audioOutput)
+}
+
+// NormalizeLoudness returns a copy of the SynthesisResult with its loudness normalized, see NormalizeLoudness.
+func (r *SynthesisResult) NormalizeLoudness(opts LoudnessOptions) (*SynthesisResult, error) {
+	audio, err := NormalizeLoudness(r.Audio, r.AudioOutput, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := *r
+	out.Audio = audio
+	return &out, nil
+}
+
+// LoudnessNormalizer returns a PostProcessor normalizing the loudness of every synthesized result, so that prompts
+// rendered by different voices and styles play back at a consistent level. e.g.
+//
+//	az.PostProcessors = append(az.PostProcessors, azuretexttospeech.LoudnessNormalizer(azuretexttospeech.LoudnessOptions{}))
+//
+// Requests must use a PCM, mu-law or A-law AudioOutput.
+func LoudnessNormalizer(opts LoudnessOptions) PostProcessor {
+	return func(r *SynthesisResult) (*SynthesisResult, error) {
+		return r.NormalizeLoudness(opts)
+	}
+}
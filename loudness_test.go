@@ -0,0 +1,61 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLoudness(t *testing.T) {
+	for _, a := range []AudioOutput{AudioRIFF24khz16bitMonoPcm, AudioRAW16Bit16kHzMonoMulaw} {
+		tone := toneFixture(t, a) // 440Hz at -6dBFS.
+		before, err := MeasureLoudness(tone, a)
+		assert.NoError(t, err)
+
+		out, err := NormalizeLoudness(tone, a, LoudnessOptions{})
+		assert.NoError(t, err)
+		assert.NotEqual(t, tone, out)
+		after, err := MeasureLoudness(out, a)
+		assert.NoError(t, err)
+		assert.InDelta(t, DefaultLoudnessTarget, after.Integrated, 0.1, a.String())
+		assert.InDelta(t, before.TruePeak+DefaultLoudnessTarget-before.Integrated, after.TruePeak, 0.1, a.String())
+
+		// the true peak ceiling takes precedence over the target.
+		out, err = NormalizeLoudness(tone, a, LoudnessOptions{Target: -3, MaxTruePeak: -2})
+		assert.NoError(t, err)
+		after, _ = MeasureLoudness(out, a)
+		assert.InDelta(t, -2, after.TruePeak, 0.1, a.String())
+		assert.True(t, after.Integrated < -3)
+	}
+
+	silence, _ := Silence(0, AudioRIFF16Bit16kHzMonoPCM)
+	out, err := NormalizeLoudness(silence, AudioRIFF16Bit16kHzMonoPCM, LoudnessOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, silence, out)
+
+	_, err = NormalizeLoudness(mp3Fixture(2), Audio16khz32kbitrateMonoMp3, LoudnessOptions{})
+	assert.Error(t, err)
+}
+
+func TestLoudnessNormalizer(t *testing.T) {
+	a := AudioRIFF24khz16bitMonoPcm
+	tone := toneFixture(t, a)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tone)
+	}))
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+		PostProcessors:  []PostProcessor{LoudnessNormalizer(LoudnessOptions{Target: -16})},
+	}
+	result, err := az.SynthesizeResultWithContext(context.Background(), "hello", LocaleEnUS, GenderFemale, a)
+	assert.NoError(t, err)
+	l, err := MeasureLoudness(result.Audio, a)
+	assert.NoError(t, err)
+	assert.InDelta(t, -16, l.Integrated, 0.1)
+}
@@ -0,0 +1,138 @@
+package azuretexttospeech
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManifestItem is a single row of a batch manifest, see ReadManifestCSV and ReadManifestJSONL.
+type ManifestItem struct {
+	ID     string `json:"id"`
+	Text   string `json:"text,omitempty"`
+	SSML   string `json:"ssml,omitempty"`
+	Voice  string `json:"voice"`
+	Format string `json:"format"` // name of the AudioOutput, e.g. "riff-8khz-8bit-mono-mulaw".
+}
+
+// Job returns the BatchJob rendering the item, keyed by its ID.
+func (m ManifestItem) Job() (BatchJob, error) {
+	if m.ID == "" {
+		return BatchJob{}, fmt.Errorf("manifest item requires an id")
+	}
+	if m.Text == "" && m.SSML == "" {
+		return BatchJob{}, fmt.Errorf("manifest item %s requires text or ssml", m.ID)
+	}
+	if m.Voice == "" && m.SSML == "" {
+		return BatchJob{}, fmt.Errorf("manifest item %s requires a voice", m.ID)
+	}
+	a, err := ParseAudioOutput(m.Format)
+	if err != nil {
+		return BatchJob{}, fmt.Errorf("manifest item %s, %v", m.ID, err)
+	}
+	return BatchJob{Key: m.ID, Text: m.Text, SSML: m.SSML, Voice: m.Voice, AudioOutput: a}, nil
+}
+
+// ReadManifestCSV reads a batch manifest from CSV. The first record is a header naming the columns id, text, voice
+// and format, in any order, optionally along with ssml. Other columns are ignored.
+func ReadManifestCSV(r io.Reader) ([]BatchJob, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read manifest header, %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"id", "voice", "format"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("manifest header lacks the %s column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var items []ManifestItem
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read manifest, %v", err)
+		}
+		items = append(items, ManifestItem{
+			ID:     strings.TrimSpace(field(record, "id")),
+			Text:   field(record, "text"),
+			SSML:   field(record, "ssml"),
+			Voice:  strings.TrimSpace(field(record, "voice")),
+			Format: strings.TrimSpace(field(record, "format")),
+		})
+	}
+	return manifestJobs(items)
+}
+
+// ReadManifestJSONL reads a batch manifest holding a JSON encoded ManifestItem per line. Blank lines are ignored.
+func ReadManifestJSONL(r io.Reader) ([]BatchJob, error) {
+	var items []ManifestItem
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; s.Scan(); line++ {
+		b := bytes.TrimSpace(s.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		var item ManifestItem
+		if err := json.Unmarshal(b, &item); err != nil {
+			return nil, fmt.Errorf("unable to read manifest line %d, %v", line, err)
+		}
+		items = append(items, item)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read manifest, %v", err)
+	}
+	return manifestJobs(items)
+}
+
+// LoadManifest reads the batch manifest at `path`, as CSV for the .csv extension and as JSON lines otherwise.
+func LoadManifest(path string) ([]BatchJob, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return ReadManifestCSV(f)
+	}
+	return ReadManifestJSONL(f)
+}
+
+// manifestJobs converts `items` into jobs, rejecting duplicate IDs as the checkpoint is keyed by them.
+func manifestJobs(items []ManifestItem) ([]BatchJob, error) {
+	jobs := make([]BatchJob, 0, len(items))
+	seen := map[string]bool{}
+	for _, item := range items {
+		job, err := item.Job()
+		if err != nil {
+			return nil, err
+		}
+		if seen[job.Key] {
+			return nil, fmt.Errorf("manifest item %s is listed more than once", job.Key)
+		}
+		seen[job.Key] = true
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,69 @@
+package azuretexttospeech
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadManifestCSV(t *testing.T) {
+	jobs, err := ReadManifestCSV(strings.NewReader(`id,voice,format,text,notes
+1,en-US-JennyNeural,riff-8khz-8bit-mono-mulaw,"Hello, world",ignored
+2,en-US-GuyNeural,audio-16khz-32kbitrate-mono-mp3,Goodbye
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []BatchJob{
+		{Key: "1", Text: "Hello, world", Voice: "en-US-JennyNeural", AudioOutput: AudioRIFF8Bit8kHzMonoPCM},
+		{Key: "2", Text: "Goodbye", Voice: "en-US-GuyNeural", AudioOutput: Audio16khz32kbitrateMonoMp3},
+	}, jobs)
+
+	_, err = ReadManifestCSV(strings.NewReader("id,text\n1,hi\n"))
+	assert.Error(t, err, "voice and format columns are required")
+	_, err = ReadManifestCSV(strings.NewReader("id,text,voice,format\n1,hi,v,bogus\n"))
+	assert.Error(t, err, "unknown format")
+	_, err = ReadManifestCSV(strings.NewReader("id,text,voice,format\n1,hi,v,riff-8khz-8bit-mono-mulaw\n1,again,v,riff-8khz-8bit-mono-mulaw\n"))
+	assert.Error(t, err, "duplicate id")
+	_, err = ReadManifestCSV(strings.NewReader("id,text,voice,format\n,hi,v,riff-8khz-8bit-mono-mulaw\n"))
+	assert.Error(t, err, "missing id")
+}
+
+func TestReadManifestJSONL(t *testing.T) {
+	jobs, err := ReadManifestJSONL(strings.NewReader(`{"id":"a","text":"Hello","voice":"en-US-JennyNeural","format":"riff-8khz-8bit-mono-mulaw"}
+
+{"id":"b","ssml":"<speak><voice name='en-US-GuyNeural'>Hi</voice></speak>","format":"riff-8khz-8bit-mono-mulaw"}
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, "a", jobs[0].Key)
+	assert.Equal(t, "<speak><voice name='en-US-GuyNeural'>Hi</voice></speak>", jobs[1].SSML)
+
+	_, err = ReadManifestJSONL(strings.NewReader(`{"id":"a","text":"Hello","format":"riff-8khz-8bit-mono-mulaw"}`))
+	assert.Error(t, err, "text requires a voice")
+	_, err = ReadManifestJSONL(strings.NewReader("{not json"))
+	assert.Error(t, err)
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "azuretts-manifest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	csvPath := filepath.Join(dir, "lines.CSV")
+	assert.NoError(t, ioutil.WriteFile(csvPath, []byte("id,text,voice,format\n1,hi,v,riff-8khz-8bit-mono-mulaw\n"), 0644))
+	jobs, err := LoadManifest(csvPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(jobs))
+
+	jsonlPath := filepath.Join(dir, "lines.jsonl")
+	assert.NoError(t, ioutil.WriteFile(jsonlPath, []byte(`{"id":"1","text":"hi","voice":"v","format":"riff-8khz-8bit-mono-mulaw"}`), 0644))
+	jobs, err = LoadManifest(jsonlPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(jobs))
+
+	_, err = LoadManifest(filepath.Join(dir, "missing.csv"))
+	assert.Error(t, err)
+}
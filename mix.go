@@ -0,0 +1,129 @@
+package azuretexttospeech
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/pcm"
+	"github.com/jesseward/azuretexttospeech/wav"
+)
+
+// Default ducking behaviour of MixOptions.
+const (
+	DefaultDuckAttack  = 50 * time.Millisecond
+	DefaultDuckRelease = 500 * time.Millisecond
+)
+
+// speechDetectAttack and speechDetectRelease shape the envelope used to detect speech. The slow release bridges the
+// short pauses between words so that the background does not pump.
+const (
+	speechDetectAttack  = 5 * time.Millisecond
+	speechDetectRelease = 250 * time.Millisecond
+)
+
+// MixOptions configures how a background track is mixed under synthesized speech.
+type MixOptions struct {
+	Volume  float64       // gain in dB applied to the background track, e.g. -18.
+	FadeIn  time.Duration // fade in of the background from the start of the mix.
+	FadeOut time.Duration // fade out of the background at the end of the mix.
+	Loop    bool          // repeat the background track when it is shorter than the speech.
+	Offset  time.Duration // delay of the speech from the start of the background, the mix is extended to match.
+	// Ducking is the additional attenuation in dB of the background while speech is present, zero disables ducking.
+	Ducking       float64
+	DuckThreshold float64       // speech level in dBFS that triggers ducking, DefaultSilenceThreshold when zero.
+	DuckAttack    time.Duration // time for the background to duck once speech starts, DefaultDuckAttack when zero.
+	DuckRelease   time.Duration // time for the background to recover once speech stops, DefaultDuckRelease when zero.
+}
+
+func (o MixOptions) duckThreshold() float64 {
+	if o.DuckThreshold == 0 {
+		return DefaultSilenceThreshold
+	}
+	return o.DuckThreshold
+}
+
+func (o MixOptions) duckAttack() time.Duration {
+	if o.DuckAttack == 0 {
+		return DefaultDuckAttack
+	}
+	return o.DuckAttack
+}
+
+func (o MixOptions) duckRelease() time.Duration {
+	if o.DuckRelease == 0 {
+		return DefaultDuckRelease
+	}
+	return o.DuckRelease
+}
+
+// MixBackground mixes the RIFF/WAVE `background` track under the PCM, mu-law or A-law `speech`, rendered in
+// `audioOutput`, and returns the mix in the same format. The background is resampled and remixed to the layout of the
+// speech, and is cut to the length of the mix. This replaces `<mstts:backgroundaudio>` for tracks that cannot be
+// published at a public URL.
+func MixBackground(speech []byte, audioOutput AudioOutput, background []byte, opts MixOptions) ([]byte, error) {
+	w, err := decodeFile(speech, audioOutput)
+	if err != nil {
+		return nil, err
+	}
+	voice, err := w.Samples()
+	if err != nil {
+		return nil, err
+	}
+	rate, channels := w.Format.SampleRate, w.Format.Channels
+
+	bg, err := wav.Decode(background)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode background, %v", err)
+	}
+	music, err := bg.Samples()
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode background, %v", err)
+	}
+	music = pcm.ResampleInterleaved(music, bg.Format.Channels, bg.Format.SampleRate, rate)
+	music = pcm.Remix(music, bg.Format.Channels, channels)
+
+	// delay the speech by the offset, the mix lasts as long as the delayed speech.
+	offset := toFrames(opts.Offset, rate) * channels
+	mix := make([]float64, offset+len(voice))
+	for len(music) < len(mix) && opts.Loop && len(music) > 0 {
+		music = append(music, music...)
+	}
+	if len(music) > len(mix) {
+		music = music[:len(mix)]
+	}
+
+	pcm.Gain(music, opts.Volume)
+	pcm.Fade(music, channels, toFrames(opts.FadeIn, rate), toFrames(opts.FadeOut, rate))
+	if opts.Ducking != 0 {
+		env := pcm.Envelope(voice, channels, float64(toFrames(speechDetectAttack, rate)), float64(toFrames(speechDetectRelease, rate)))
+		gain := pcm.Duck(env, opts.duckThreshold(), opts.Ducking, float64(toFrames(opts.duckAttack(), rate)), float64(toFrames(opts.duckRelease(), rate)))
+		for i, g := range gain {
+			for c := 0; c < channels; c++ {
+				if k := offset + i*channels + c; k < len(music) {
+					music[k] *= g
+				}
+			}
+		}
+	}
+
+	pcm.Mix(mix, music)
+	pcm.Mix(mix[offset:], voice)
+
+	out := *w
+	if err := out.SetSamples(mix); err != nil {
+		return nil, err
+	}
+	return encodeFile(&out, audioOutput)
+}
+
+// MixBackground returns a copy of the SynthesisResult with `background` mixed under the speech, see MixBackground.
+func (r *SynthesisResult) MixBackground(background []byte, opts MixOptions) (*SynthesisResult, error) {
+	audio, err := MixBackground(r.Audio, r.AudioOutput, background, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := *r
+	out.Audio = audio
+	out.Duration = audioDuration(audio, r.AudioOutput)
+	return &out, nil
+}
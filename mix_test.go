@@ -0,0 +1,71 @@
+package azuretexttospeech
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/pcm"
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+// backgroundFixture returns a stereo 44.1kHz WAV holding a constant level of 0.25 lasting `d`.
+func backgroundFixture(t *testing.T, d time.Duration) []byte {
+	w := wav.New(wav.Format{Tag: wav.FormatPCM, Channels: 2, SampleRate: 44100, BitsPerSample: 16}, nil)
+	s := make([]float64, 2*toFrames(d, 44100))
+	for i := range s {
+		s[i] = 0.25
+	}
+	assert.NoError(t, w.SetSamples(s))
+	return w.Bytes()
+}
+
+func TestMixBackground(t *testing.T) {
+	a := AudioRIFF24khz16bitMonoPcm
+	lead, _ := Silence(500*time.Millisecond, a)
+	speech, err := JoinWithSilence(0, a, lead, toneFixture(t, a))
+	assert.NoError(t, err)
+	voice, _, _ := decodeSamples(speech, a)
+
+	// background returns the background component of the mix at `frame`.
+	background := func(out []byte, frame int) float64 {
+		mixed, _, err := decodeSamples(out, a)
+		assert.NoError(t, err)
+		assert.Equal(t, len(voice), len(mixed))
+		return mixed[frame] - voice[frame]
+	}
+
+	out, err := MixBackground(speech, a, backgroundFixture(t, 3*time.Second), MixOptions{Volume: -6.0206})
+	assert.NoError(t, err)
+	assert.NoError(t, validateAudio(out, "", a))
+	assert.InDelta(t, 0.125, background(out, 6000), 1e-3)
+	assert.InDelta(t, 0.125, background(out, 24000), 1e-3)
+
+	// the background ducks by 12dB under the speech, but not before it.
+	out, err = MixBackground(speech, a, backgroundFixture(t, 3*time.Second), MixOptions{Volume: -6.0206, Ducking: -12})
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.125, background(out, 6000), 1e-3)
+	assert.InDelta(t, 0.125*pcm.Amplitude(-12), background(out, 24000), 1e-3)
+
+	// a short background loops under the whole speech, and fades.
+	out, err = MixBackground(speech, a, backgroundFixture(t, 200*time.Millisecond), MixOptions{Loop: true, FadeIn: 100 * time.Millisecond, FadeOut: 100 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.InDelta(t, 0, background(out, 0), 1e-3)
+	assert.InDelta(t, 0.125, background(out, 1200), 2e-3, "halfway through the fade in")
+	assert.InDelta(t, 0.25, background(out, 30000), 2e-3)
+	assert.InDelta(t, 0, background(out, len(voice)-1), 1e-3)
+
+	// without looping the background stops.
+	out, err = MixBackground(speech, a, backgroundFixture(t, 200*time.Millisecond), MixOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, background(out, 30000))
+
+	// the speech can start after an introduction.
+	r := &SynthesisResult{Audio: speech, AudioOutput: a}
+	mixed, err := r.MixBackground(backgroundFixture(t, 3*time.Second), MixOptions{Offset: time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, 2500*time.Millisecond, mixed.Duration)
+
+	_, err = MixBackground(speech, a, []byte("RIFF"), MixOptions{})
+	assert.Error(t, err)
+}
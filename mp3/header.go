@@ -0,0 +1,175 @@
+package mp3
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Version is the MPEG audio version of a frame.
+type Version int
+
+const (
+	MPEG1  Version = iota // ISO/IEC 11172-3
+	MPEG2                 // ISO/IEC 13818-3, low sampling frequencies.
+	MPEG25                // unofficial extension to 8kHz, 11.025kHz and 12kHz.
+)
+
+func (v Version) String() string {
+	switch v {
+	case MPEG1:
+		return "MPEG-1"
+	case MPEG2:
+		return "MPEG-2"
+	case MPEG25:
+		return "MPEG-2.5"
+	}
+	return fmt.Sprintf("Version(%d)", v)
+}
+
+// ChannelMode describes the channel layout of a frame.
+type ChannelMode int
+
+const (
+	Stereo ChannelMode = iota
+	JointStereo
+	DualChannel
+	Mono
+)
+
+// HeaderSize is the size in bytes of the frame header, excluding the optional CRC.
+const HeaderSize = 4
+
+// ErrNoSync is returned when the input does not start with an MPEG audio frame header.
+var ErrNoSync = errors.New("mp3: missing frame sync")
+
+// Header is a decoded MPEG audio frame header.
+type Header struct {
+	Version     Version
+	Layer       int // 1, 2 or 3.
+	Protected   bool
+	Bitrate     int // bits per second.
+	SampleRate  int
+	Padding     bool
+	ChannelMode ChannelMode
+}
+
+// bitrates in kbit/s indexed by [version is MPEG1][layer-1][index].
+var bitrates = [2][3][15]int{
+	{ // MPEG-2 and MPEG-2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+	{ // MPEG-1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+}
+
+// sampleRates indexed by [Version][index].
+var sampleRates = [3][3]int{
+	MPEG1:  {44100, 48000, 32000},
+	MPEG2:  {22050, 24000, 16000},
+	MPEG25: {11025, 12000, 8000},
+}
+
+// ParseHeader decodes the four byte frame header at the start of `b`. Free format and reserved values are rejected.
+func ParseHeader(b []byte) (Header, error) {
+	if len(b) < HeaderSize || b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return Header{}, ErrNoSync
+	}
+
+	var h Header
+	switch (b[1] >> 3) & 0x03 {
+	case 0:
+		h.Version = MPEG25
+	case 2:
+		h.Version = MPEG2
+	case 3:
+		h.Version = MPEG1
+	default:
+		return Header{}, fmt.Errorf("mp3: reserved version")
+	}
+
+	layer := (b[1] >> 1) & 0x03
+	if layer == 0 {
+		return Header{}, fmt.Errorf("mp3: reserved layer")
+	}
+	h.Layer = int(4 - layer)
+	h.Protected = b[1]&0x01 == 0
+
+	bitrateIndex := int(b[2] >> 4)
+	if bitrateIndex == 0 || bitrateIndex == 15 {
+		return Header{}, fmt.Errorf("mp3: unsupported bitrate index %d", bitrateIndex)
+	}
+	mpeg1 := 0
+	if h.Version == MPEG1 {
+		mpeg1 = 1
+	}
+	h.Bitrate = bitrates[mpeg1][h.Layer-1][bitrateIndex] * 1000
+
+	rateIndex := int(b[2]>>2) & 0x03
+	if rateIndex == 3 {
+		return Header{}, fmt.Errorf("mp3: reserved sample rate")
+	}
+	h.SampleRate = sampleRates[h.Version][rateIndex]
+	h.Padding = b[2]&0x02 != 0
+	h.ChannelMode = ChannelMode(b[3] >> 6)
+	return h, nil
+}
+
+// SamplesPerFrame returns the number of samples, per channel, decoded from the frame.
+func (h Header) SamplesPerFrame() int {
+	switch {
+	case h.Layer == 1:
+		return 384
+	case h.Layer == 3 && h.Version != MPEG1:
+		return 576
+	}
+	return 1152
+}
+
+// FrameSize returns the size in bytes of the frame, including the header.
+func (h Header) FrameSize() int {
+	pad := 0
+	if h.Padding {
+		pad = 1
+	}
+	if h.Layer == 1 {
+		return (12*h.Bitrate/h.SampleRate + pad) * 4
+	}
+	return h.SamplesPerFrame()/8*h.Bitrate/h.SampleRate + pad
+}
+
+// Duration returns the playback duration of the frame.
+func (h Header) Duration() time.Duration {
+	return time.Duration(int64(h.SamplesPerFrame()) * int64(time.Second) / int64(h.SampleRate))
+}
+
+// Channels returns the number of channels of the frame.
+func (h Header) Channels() int {
+	if h.ChannelMode == Mono {
+		return 1
+	}
+	return 2
+}
+
+// sideInfoSize returns the size of the layer III side information following the header and CRC.
+func (h Header) sideInfoSize() int {
+	switch {
+	case h.Version == MPEG1 && h.ChannelMode == Mono:
+		return 17
+	case h.Version == MPEG1:
+		return 32
+	case h.ChannelMode == Mono:
+		return 9
+	}
+	return 17
+}
+
+// compatible returns true if frames with headers `h` and `o` can be joined within a single stream.
+func (h Header) compatible(o Header) bool {
+	return h.Version == o.Version && h.Layer == o.Layer && h.SampleRate == o.SampleRate && h.Channels() == o.Channels()
+}
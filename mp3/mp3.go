@@ -0,0 +1,316 @@
+/*
+Package mp3 works with MPEG audio streams, such as the `audio-*-mp3` outputs of the Azure text-to-speech API, at the
+frame level. It walks frames to compute exact durations, strips ID3 tags and Xing/Info/VBRI header frames,
+concatenates streams and cuts them at frame boundaries without decoding the audio.
+*/
+package mp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Frame is a single MPEG audio frame.
+type Frame struct {
+	Header Header
+	Data   []byte // the complete frame, including the header.
+}
+
+// Stream is a parsed MPEG audio stream.
+type Stream struct {
+	ID3v2  []byte // leading ID3v2 tag, including its header.
+	Info   *Frame // Xing, Info or VBRI frame describing the stream; it carries no audio.
+	Frames []Frame
+	APE    []byte // trailing APEv2 tag.
+	ID3v1  []byte // trailing 128 byte ID3v1 tag.
+
+	// EncoderDelay and EncoderPadding are the number of samples added by the encoder at the start and end of the
+	// stream, as recorded by the LAME tag of the Info frame. They are zero when no LAME tag is present.
+	EncoderDelay   int
+	EncoderPadding int
+}
+
+// ID3v2Size returns the total size of the ID3v2 tag at the start of `b`, or zero when `b` does not start with a tag.
+func ID3v2Size(b []byte) int {
+	if len(b) < 10 || string(b[0:3]) != "ID3" {
+		return 0
+	}
+	// the tag size is a 28 bit syncsafe integer, excluding the 10 byte header.
+	size := 10 + (int(b[6]&0x7f)<<21 | int(b[7]&0x7f)<<14 | int(b[8]&0x7f)<<7 | int(b[9]&0x7f))
+	if b[5]&0x10 != 0 {
+		size += 10 // footer present.
+	}
+	return size
+}
+
+// Parse walks the frames of the MPEG audio stream `b`. Garbage between frames is skipped by searching for the next
+// frame sync, and a truncated final frame is dropped.
+func Parse(b []byte) (*Stream, error) {
+	s := &Stream{}
+	start, end := 0, len(b)
+
+	if n := ID3v2Size(b); n > 0 {
+		if n > len(b) {
+			return nil, fmt.Errorf("mp3: ID3v2 tag of %d bytes exceeds the input", n)
+		}
+		s.ID3v2 = b[:n]
+		start = n
+	}
+	if end-start >= 128 && string(b[end-128:end-125]) == "TAG" {
+		s.ID3v1 = b[end-128:]
+		end -= 128
+	}
+	if n := apeSize(b[start:end]); n > 0 {
+		s.APE = b[end-n : end]
+		end -= n
+	}
+
+	for i := start; i+HeaderSize <= end; {
+		h, err := ParseHeader(b[i:end])
+		if err != nil {
+			next := resync(b[:end], i+1)
+			if next < 0 {
+				break
+			}
+			i = next
+			continue
+		}
+		size := h.FrameSize()
+		if i+size > end {
+			break
+		}
+
+		f := Frame{Header: h, Data: b[i : i+size]}
+		if len(s.Frames) == 0 && s.Info == nil && isInfoFrame(f) {
+			s.Info = &f
+			s.EncoderDelay, s.EncoderPadding = lameDelay(f)
+		} else {
+			s.Frames = append(s.Frames, f)
+		}
+		i += size
+	}
+
+	if len(s.Frames) == 0 && s.Info == nil {
+		return nil, ErrNoSync
+	}
+	return s, nil
+}
+
+// resync returns the offset of the next frame header at or after `from` that is followed by another valid header,
+// or -1 when none is found.
+func resync(b []byte, from int) int {
+	for i := from; i+HeaderSize <= len(b); i++ {
+		j := bytes.IndexByte(b[i:], 0xff)
+		if j < 0 {
+			return -1
+		}
+		i += j
+		h, err := ParseHeader(b[i:])
+		if err != nil {
+			continue
+		}
+		next := i + h.FrameSize()
+		if next == len(b) {
+			return i
+		}
+		if next < len(b) {
+			if o, err := ParseHeader(b[next:]); err == nil && h.compatible(o) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// apeSize returns the size of an APEv2 tag at the end of `b`, or zero when no tag is present.
+func apeSize(b []byte) int {
+	if len(b) < 32 || string(b[len(b)-32:len(b)-24]) != "APETAGEX" {
+		return 0
+	}
+	footer := b[len(b)-32:]
+	size := int(binary.LittleEndian.Uint32(footer[12:16]))
+	if binary.LittleEndian.Uint32(footer[20:24])&(1<<31) != 0 {
+		size += 32 // header present.
+	}
+	if size > len(b) {
+		return 0
+	}
+	return size
+}
+
+// infoOffset returns the offset of the Xing/Info tag within a layer III frame.
+func infoOffset(h Header) int {
+	off := HeaderSize + h.sideInfoSize()
+	if h.Protected {
+		off += 2
+	}
+	return off
+}
+
+// isInfoFrame returns true for Xing, Info and VBRI frames.
+func isInfoFrame(f Frame) bool {
+	if f.Header.Layer != 3 {
+		return false
+	}
+	off := infoOffset(f.Header)
+	if len(f.Data) >= off+4 {
+		if tag := string(f.Data[off : off+4]); tag == "Xing" || tag == "Info" {
+			return true
+		}
+	}
+	return len(f.Data) >= 40 && string(f.Data[36:40]) == "VBRI"
+}
+
+// lameDelay returns the encoder delay and padding recorded by the LAME tag of a Xing/Info frame.
+func lameDelay(f Frame) (int, int) {
+	off := infoOffset(f.Header)
+	d := f.Data
+	if len(d) < off+8 || (string(d[off:off+4]) != "Xing" && string(d[off:off+4]) != "Info") {
+		return 0, 0
+	}
+	flags := binary.BigEndian.Uint32(d[off+4 : off+8])
+	p := off + 8
+	for _, field := range []struct {
+		flag uint32
+		size int
+	}{{0x01, 4}, {0x02, 4}, {0x04, 100}, {0x08, 4}} {
+		if flags&field.flag != 0 {
+			p += field.size
+		}
+	}
+	// the LAME tag starts with a 9 byte encoder version, the delay and padding are found 21 bytes in.
+	if len(d) < p+24 {
+		return 0, 0
+	}
+	if v := string(d[p : p+4]); v != "LAME" && v != "Lavc" && v != "Lavf" {
+		return 0, 0
+	}
+	x := d[p+21 : p+24]
+	return int(x[0])<<4 | int(x[1])>>4, int(x[1]&0x0f)<<8 | int(x[2])
+}
+
+// Samples returns the number of samples, per channel, within the audio frames of the stream.
+func (s *Stream) Samples() int {
+	n := 0
+	for _, f := range s.Frames {
+		n += f.Header.SamplesPerFrame()
+	}
+	return n
+}
+
+// Duration returns the exact duration of the audio frames, including encoder delay and padding.
+func (s *Stream) Duration() time.Duration {
+	// accumulate samples per sample rate to avoid rounding on every frame.
+	samples := make(map[int]int64)
+	for _, f := range s.Frames {
+		samples[f.Header.SampleRate] += int64(f.Header.SamplesPerFrame())
+	}
+	var d time.Duration
+	for rate, n := range samples {
+		d += time.Duration(n * int64(time.Second) / int64(rate))
+	}
+	return d
+}
+
+// PlaybackDuration returns the duration of the audio after removing the encoder delay and padding.
+func (s *Stream) PlaybackDuration() time.Duration {
+	if len(s.Frames) == 0 {
+		return 0
+	}
+	rate := int64(s.Frames[0].Header.SampleRate)
+	trim := time.Duration(int64(s.EncoderDelay+s.EncoderPadding) * int64(time.Second) / rate)
+	if d := s.Duration() - trim; d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Bytes encodes the Stream, writing the tags and Info frame that are set.
+func (s *Stream) Bytes() []byte {
+	var b bytes.Buffer
+	b.Write(s.ID3v2)
+	if s.Info != nil {
+		b.Write(s.Info.Data)
+	}
+	for _, f := range s.Frames {
+		b.Write(f.Data)
+	}
+	b.Write(s.APE)
+	b.Write(s.ID3v1)
+	return b.Bytes()
+}
+
+// Strip returns a copy of the Stream holding only the audio frames.
+func (s *Stream) Strip() *Stream {
+	return &Stream{Frames: s.Frames}
+}
+
+// Slice returns the audio frames overlapping the range between `start` and `end`. A zero or out of range `end`
+// selects the remainder of the stream. Frames are not decoded, as such the first frame of the slice may reference
+// bit reservoir data of a dropped frame and decode with a brief glitch.
+func (s *Stream) Slice(start, end time.Duration) *Stream {
+	out := &Stream{}
+	var pos time.Duration
+	for _, f := range s.Frames {
+		frameEnd := pos + f.Header.Duration()
+		if frameEnd > start && (end <= 0 || pos < end) {
+			out.Frames = append(out.Frames, f)
+		}
+		pos = frameEnd
+	}
+	return out
+}
+
+// Strip removes ID3 and APE tags and the Xing/Info/VBRI frame from `b`, returning the bare audio frames.
+func Strip(b []byte) ([]byte, error) {
+	s, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	return s.Strip().Bytes(), nil
+}
+
+// Duration returns the exact duration of the audio frames of `b`.
+func Duration(b []byte) (time.Duration, error) {
+	s, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return s.Duration(), nil
+}
+
+// Cut returns the audio frames of `b` overlapping the range between `start` and `end`, see Stream.Slice.
+func Cut(b []byte, start, end time.Duration) ([]byte, error) {
+	s, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	return s.Slice(start, end).Bytes(), nil
+}
+
+// Concat joins the audio frames of `streams` into a single stream. Tags and Info frames are dropped since they no
+// longer describe the result. All streams must share the MPEG version, layer, sample rate and channel count.
+func Concat(streams ...[]byte) ([]byte, error) {
+	var out bytes.Buffer
+	var first *Header
+	for i, b := range streams {
+		s, err := Parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("mp3: stream %d, %v", i, err)
+		}
+		for _, f := range s.Frames {
+			if first == nil {
+				h := f.Header
+				first = &h
+			}
+			if !first.compatible(f.Header) {
+				return nil, fmt.Errorf("mp3: stream %d is %s layer %d at %dHz, expected %s layer %d at %dHz",
+					i, f.Header.Version, f.Header.Layer, f.Header.SampleRate, first.Version, first.Layer, first.SampleRate)
+			}
+			out.Write(f.Data)
+		}
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,216 @@
+package mp3
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mpeg2Frame is the header of a 144 byte MPEG-2 layer III frame at 16kHz, 32kbit/s, mono; the format of
+// audio-16khz-32kbitrate-mono-mp3.
+var mpeg2Frame = []byte{0xff, 0xf3, 0x48, 0xc0}
+
+// mpeg1Frame is the header of a 576 byte MPEG-1 layer III frame at 48kHz, 192kbit/s, mono.
+var mpeg1Frame = []byte{0xff, 0xfb, 0xb4, 0xc0}
+
+func frames(header []byte, n int) []byte {
+	h, _ := ParseHeader(header)
+	var b bytes.Buffer
+	for i := 0; i < n; i++ {
+		f := make([]byte, h.FrameSize())
+		copy(f, header)
+		f[10] = byte(i) // tag each frame to follow it around.
+		b.Write(f)
+	}
+	return b.Bytes()
+}
+
+// infoFrame returns a Xing/Info frame carrying a LAME tag with the given delay and padding.
+func infoFrame(delay, padding int) []byte {
+	f := frames(mpeg2Frame, 1)
+	off := 4 + 9
+	copy(f[off:], "Info\x00\x00\x00\x03")
+	copy(f[off+16:], "LAME3.100")
+	x := f[off+16+21:]
+	x[0], x[1], x[2] = byte(delay>>4), byte(delay<<4)|byte(padding>>8), byte(padding)
+	return f
+}
+
+func id3v2(size int) []byte {
+	b := make([]byte, 10+size)
+	copy(b, "ID3\x04\x00\x00")
+	b[8], b[9] = byte(size>>7)&0x7f, byte(size)&0x7f
+	return b
+}
+
+func TestParseHeader(t *testing.T) {
+	h, err := ParseHeader(mpeg2Frame)
+	assert.NoError(t, err)
+	assert.Equal(t, Header{Version: MPEG2, Layer: 3, Bitrate: 32000, SampleRate: 16000, ChannelMode: Mono}, h)
+	assert.Equal(t, 144, h.FrameSize())
+	assert.Equal(t, 576, h.SamplesPerFrame())
+	assert.Equal(t, 36*time.Millisecond, h.Duration())
+
+	h, err = ParseHeader(mpeg1Frame)
+	assert.NoError(t, err)
+	assert.Equal(t, MPEG1, h.Version)
+	assert.Equal(t, 576, h.FrameSize())
+	assert.Equal(t, 24*time.Millisecond, h.Duration())
+
+	_, err = ParseHeader([]byte("<htm"))
+	assert.Equal(t, ErrNoSync, err)
+	_, err = ParseHeader([]byte{0xff, 0xf3, 0x08, 0xc0})
+	assert.Error(t, err, "free format bitrates are not supported")
+}
+
+func TestParse(t *testing.T) {
+	audio := frames(mpeg2Frame, 25)
+	b := append(append(id3v2(20), infoFrame(576, 288)...), audio...)
+	b = append(b, append([]byte("TAG"), make([]byte, 125)...)...)
+
+	s, err := Parse(b)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, len(s.ID3v2))
+	assert.NotNil(t, s.Info)
+	assert.Equal(t, 128, len(s.ID3v1))
+	assert.Equal(t, 25, len(s.Frames))
+	assert.Equal(t, 900*time.Millisecond, s.Duration())
+	assert.Equal(t, 576, s.EncoderDelay)
+	assert.Equal(t, 288, s.EncoderPadding)
+	assert.Equal(t, 900*time.Millisecond-54*time.Millisecond, s.PlaybackDuration())
+	assert.Equal(t, b, s.Bytes())
+
+	stripped, err := Strip(b)
+	assert.NoError(t, err)
+	assert.Equal(t, audio, stripped)
+
+	_, err = Parse([]byte("<html></html>"))
+	assert.Equal(t, ErrNoSync, err)
+}
+
+func TestParseResync(t *testing.T) {
+	b := append(frames(mpeg2Frame, 2), 0xff, 0x00, 0xff, 0x12)
+	b = append(b, frames(mpeg2Frame, 3)...)
+	b = append(b, mpeg2Frame...) // truncated final frame.
+
+	s, err := Parse(b)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(s.Frames))
+}
+
+func TestSliceAndCut(t *testing.T) {
+	b := frames(mpeg2Frame, 50) // 1.8 seconds.
+
+	s, err := Parse(b)
+	assert.NoError(t, err)
+	assert.Equal(t, 1800*time.Millisecond, s.Duration())
+
+	part := s.Slice(360*time.Millisecond, 720*time.Millisecond)
+	assert.Equal(t, 10, len(part.Frames))
+	assert.Equal(t, byte(10), part.Frames[0].Data[10])
+
+	// boundaries within a frame include the overlapping frame.
+	part = s.Slice(370*time.Millisecond, 0)
+	assert.Equal(t, 40, len(part.Frames))
+
+	cut, err := Cut(b, 0, 100*time.Millisecond)
+	assert.NoError(t, err)
+	d, err := Duration(cut)
+	assert.NoError(t, err)
+	assert.Equal(t, 108*time.Millisecond, d)
+}
+
+func TestConcat(t *testing.T) {
+	a := append(append(id3v2(10), infoFrame(0, 0)...), frames(mpeg2Frame, 10)...)
+	b := frames(mpeg2Frame, 5)
+
+	joined, err := Concat(a, b)
+	assert.NoError(t, err)
+	d, err := Duration(joined)
+	assert.NoError(t, err)
+	assert.Equal(t, 540*time.Millisecond, d)
+	assert.Equal(t, 15*144, len(joined), "tags and Info frames are dropped")
+
+	_, err = Concat(a, frames(mpeg1Frame, 2))
+	assert.Error(t, err, "sample rates differ")
+}
+
+// loudFrame returns an MPEG-2 mono frame whose side information describes a granule with `bigValues` big values at
+// global gain `gain`.
+func loudFrame(bigValues, gain int) []byte {
+	f := frames(mpeg2Frame, 1)
+	pos := HeaderSize*8 + 8 + 1 // main_data_begin and private bits.
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			if v>>uint(i)&1 == 1 {
+				f[pos>>3] |= 0x80 >> uint(pos&7)
+			}
+			pos++
+		}
+	}
+	put(500, 12)
+	put(bigValues, 9)
+	put(gain, 8)
+	return f
+}
+
+func TestCoefficientLevel(t *testing.T) {
+	s, err := Parse(append(append(frames(mpeg2Frame, 3), loudFrame(100, 200)...), append(loudFrame(0, 150), frames(mpeg2Frame, 2)...)...))
+	assert.NoError(t, err)
+
+	g, err := s.Frames[3].Granules()
+	assert.NoError(t, err)
+	assert.Equal(t, []Granule{{Part23Length: 500, BigValues: 100, GlobalGain: 200}}, g)
+	assert.True(t, math.IsInf(s.Frames[3].CoefficientLevel(), 1), "big values cannot be bounded")
+	assert.InDelta(t, -90.3, s.Frames[4].CoefficientLevel(), 0.1)
+	assert.True(t, math.IsInf(s.Frames[0].CoefficientLevel(), -1), "frames without main data are digital silence")
+
+	lead, trail := s.SilentEdges(-60)
+	assert.Equal(t, 3, lead)
+	assert.Equal(t, 3, trail)
+}
+
+func TestTrim(t *testing.T) {
+	// the main data of the loud frame begins 200 bytes back, within the last two of the leading frames.
+	loud := loudFrame(100, 200)
+	loud[HeaderSize] = 200
+	s, err := Parse(append(append(frames(mpeg2Frame, 3), loud...), frames(mpeg2Frame, 1)...))
+	assert.NoError(t, err)
+	n, err := s.Frames[3].MainDataBegin()
+	assert.NoError(t, err)
+	assert.Equal(t, 200, n)
+
+	out := s.Trim(3, 1)
+	assert.Len(t, out.Frames, 3)
+	for i, f := range out.Frames[:2] {
+		assert.Equal(t, make([]byte, 9), f.Data[HeaderSize:HeaderSize+9], "carriers decode to silence")
+		assert.Equal(t, s.Frames[i+1].Data[HeaderSize+9:], f.Data[HeaderSize+9:], "carriers keep their main data")
+	}
+	assert.Equal(t, s.Frames[2].Data[10], byte(2), "the stream is left untouched")
+	assert.Equal(t, loud, out.Frames[2].Data)
+
+	// without reservoir data, no frame is kept back.
+	out = s.Trim(1, 1)
+	assert.Len(t, out.Frames, 3)
+	assert.Equal(t, s.Frames[1:4], out.Frames)
+	assert.Empty(t, s.Trim(3, 2).Frames)
+}
+
+func TestCRC16(t *testing.T) {
+	assert.Equal(t, uint16(0xaee7), crc16([]byte("123456789")))
+}
+
+func TestSilentFrame(t *testing.T) {
+	for _, header := range [][]byte{mpeg2Frame, mpeg1Frame} {
+		h, _ := ParseHeader(header)
+		b := SilentFrame(h)
+		parsed, err := ParseHeader(b)
+		assert.NoError(t, err)
+		assert.Equal(t, h, parsed)
+		assert.Equal(t, h.FrameSize(), len(b))
+		assert.True(t, Frame{Header: parsed, Data: b}.CoefficientLevel() < -100)
+	}
+}
@@ -0,0 +1,230 @@
+package mp3
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Granule holds the fields of the layer III side information that describe one granule of one channel.
+type Granule struct {
+	Part23Length int // bits of main data used by the scale factors and Huffman coded spectrum.
+	BigValues    int // pairs of spectral values coded with the big value tables, zero when all values are within ±1.
+	GlobalGain   int // quantizer step size.
+}
+
+// bitReader reads big-endian bit fields.
+type bitReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v = v<<1 | int(r.b[r.pos>>3]>>(7-uint(r.pos&7))&1)
+		r.pos++
+	}
+	return v
+}
+
+// Granules decodes the side information of a layer III frame, returning its granules in decoding order, one per
+// channel of each granule.
+func (f Frame) Granules() ([]Granule, error) {
+	h := f.Header
+	if h.Layer != 3 {
+		return nil, fmt.Errorf("mp3: side information is only defined for layer III")
+	}
+	off := f.sideInfoOffset()
+	if len(f.Data) < off+h.sideInfoSize() {
+		return nil, fmt.Errorf("mp3: truncated side information")
+	}
+
+	r := &bitReader{b: f.Data[off : off+h.sideInfoSize()]}
+	channels := h.Channels()
+	granules, granuleBits := 1, 63
+	if h.Version == MPEG1 {
+		granules, granuleBits = 2, 59
+		r.read(9) // main_data_begin
+		if channels == 1 {
+			r.read(5) // private_bits
+		} else {
+			r.read(3)
+		}
+		r.read(4 * channels) // scfsi
+	} else {
+		r.read(8) // main_data_begin
+		r.read(channels)
+	}
+
+	out := make([]Granule, 0, granules*channels)
+	for i := 0; i < granules*channels; i++ {
+		start := r.pos
+		out = append(out, Granule{Part23Length: r.read(12), BigValues: r.read(9), GlobalGain: r.read(8)})
+		r.pos = start + granuleBits
+	}
+	return out, nil
+}
+
+// CoefficientLevel returns an upper bound of the magnitude of the spectral coefficients of the frame, in dB relative
+// to a full scale coefficient, from its side information alone. Granules without spectral data decode to digital
+// silence. Granules without big values only hold quantized values within ±1, which decode to at most
+// 2^((global_gain-210)/4) as scale factors can only attenuate them. Big values cannot be bounded without decoding the
+// Huffman coded spectrum, frames carrying them, or whose side information is unreadable, report +Inf. The bound is not
+// the peak level of the decoded samples, which sum many coefficients, but reliably separates encoded silence and faint
+// noise from speech.
+func (f Frame) CoefficientLevel() float64 {
+	granules, err := f.Granules()
+	if err != nil {
+		return math.Inf(1)
+	}
+	level := math.Inf(-1)
+	for _, g := range granules {
+		if g.Part23Length == 0 {
+			continue
+		}
+		if g.BigValues > 0 {
+			return math.Inf(1)
+		}
+		if db := 20 * math.Log10(2) * float64(g.GlobalGain-210) / 4; db > level {
+			level = db
+		}
+	}
+	return level
+}
+
+// sideInfoOffset returns the offset of the side information within the frame, following the header and the CRC.
+func (f Frame) sideInfoOffset() int {
+	if f.Header.Protected {
+		return HeaderSize + 2
+	}
+	return HeaderSize
+}
+
+// MainDataBegin returns the number of bytes of main data of the frame that are stored within the preceding frames, the
+// bit reservoir. Frames referencing the reservoir cannot be decoded without their predecessors.
+func (f Frame) MainDataBegin() (int, error) {
+	off := f.sideInfoOffset()
+	if f.Header.Layer != 3 || len(f.Data) < off+f.Header.sideInfoSize() {
+		return 0, fmt.Errorf("mp3: frame has no readable side information")
+	}
+	r := &bitReader{b: f.Data[off:]}
+	if f.Header.Version == MPEG1 {
+		return r.read(9), nil
+	}
+	return r.read(8), nil
+}
+
+// mainDataSize returns the number of bytes following the side information of the frame, which hold main data of the
+// frame itself or of the frames that follow it.
+func (f Frame) mainDataSize() int {
+	n := len(f.Data) - f.sideInfoOffset() - f.Header.sideInfoSize()
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// silenced returns a copy of the frame with its side information cleared, so that it decodes to digital silence
+// while still carrying its main data bytes for the reservoir of the frames that follow.
+func (f Frame) silenced() Frame {
+	b := append([]byte(nil), f.Data...)
+	off := f.sideInfoOffset()
+	for i := off; i < off+f.Header.sideInfoSize() && i < len(b); i++ {
+		b[i] = 0
+	}
+	if f.Header.Protected && len(b) >= off+f.Header.sideInfoSize() {
+		binary.BigEndian.PutUint16(b[HeaderSize:], crc16(append(append([]byte(nil), b[2:4]...), b[off:off+f.Header.sideInfoSize()]...)))
+	}
+	return Frame{Header: f.Header, Data: b}
+}
+
+// crc16 computes the CRC protecting the header and side information of a frame, CRC-16 with polynomial 0x8005 and an
+// initial value of 0xffff.
+func crc16(b []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, c := range b {
+		crc ^= uint16(c) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Trim returns the stream without its first `lead` and last `trail` audio frames. When the first frame kept
+// references bit reservoir data held by dropped frames, the dropped frames holding it are kept as well, with their side
+// information cleared so that they decode to silence, and the kept audio decodes without a glitch. The Info frame is
+// dropped as it no longer describes the stream.
+func (s *Stream) Trim(lead, trail int) *Stream {
+	out := *s
+	out.Info = nil
+	out.EncoderDelay, out.EncoderPadding = 0, 0
+	if lead+trail >= len(s.Frames) {
+		out.Frames = nil
+		return &out
+	}
+	frames := s.Frames[lead : len(s.Frames)-trail]
+
+	var carriers []Frame
+	if need, err := frames[0].MainDataBegin(); err == nil {
+		for i := lead - 1; i >= 0 && need > 0; i-- {
+			carriers = append([]Frame{s.Frames[i].silenced()}, carriers...)
+			need -= s.Frames[i].mainDataSize()
+		}
+	}
+	out.Frames = append(carriers, frames...)
+	return &out
+}
+
+// SilentFrame returns a frame with the layout of `h` that decodes to silence: empty side information and no main data.
+func SilentFrame(h Header) []byte {
+	h.Padding = false
+	b := make([]byte, h.FrameSize())
+	b[0] = 0xff
+
+	var version byte
+	switch h.Version {
+	case MPEG1:
+		version = 3
+	case MPEG2:
+		version = 2
+	}
+	b[1] = 0xe0 | version<<3 | byte(4-h.Layer)<<1 | 0x01 // no CRC.
+
+	mpeg1 := 0
+	if h.Version == MPEG1 {
+		mpeg1 = 1
+	}
+	for i, kbps := range bitrates[mpeg1][h.Layer-1] {
+		if kbps*1000 == h.Bitrate {
+			b[2] = byte(i) << 4
+		}
+	}
+	for i, rate := range sampleRates[h.Version] {
+		if rate == h.SampleRate {
+			b[2] |= byte(i) << 2
+		}
+	}
+	b[3] = byte(h.ChannelMode) << 6
+	return b
+}
+
+// SilentEdges returns the number of leading and trailing audio frames with a CoefficientLevel below `threshold`, in dB
+// relative to a full scale spectral coefficient.
+func (s *Stream) SilentEdges(threshold float64) (lead, trail int) {
+	for lead < len(s.Frames) && s.Frames[lead].CoefficientLevel() < threshold {
+		lead++
+	}
+	if lead == len(s.Frames) {
+		return lead, 0
+	}
+	for trail < len(s.Frames)-lead && s.Frames[len(s.Frames)-1-trail].CoefficientLevel() < threshold {
+		trail++
+	}
+	return lead, trail
+}
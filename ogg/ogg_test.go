@@ -0,0 +1,149 @@
+package ogg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// opusFixture returns an Ogg Opus stream of `n` 20ms CELT packets, with a pre-skip of 312 samples and the final
+// packet trimmed by `trim` samples.
+func opusFixture(serial uint32, n int, trim int64) []byte {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8], head[9] = 1, 1
+	binary.LittleEndian.PutUint16(head[10:], 312)
+	binary.LittleEndian.PutUint32(head[12:], 24000)
+
+	tags := append([]byte("OpusTags\x04\x00\x00\x00test"), 0, 0, 0, 0)
+
+	s := &OpusStream{Serial: serial, Head: head, Tags: tags}
+	for i := 0; i < n; i++ {
+		p := bytes.Repeat([]byte{byte(i)}, 60)
+		p[0] = 0xf8 // CELT-only, 20ms, one frame.
+		s.Packets = append(s.Packets, p)
+	}
+	s.Granule = int64(n)*960 - trim
+	return s.Bytes()
+}
+
+func TestChecksum(t *testing.T) {
+	// the Ogg CRC has no reflection, initial value or final xor.
+	assert.Equal(t, uint32(0x89a1897f), checksum([]byte("123456789")))
+
+	p := Page{Flags: FlagBOS, Serial: 7, Segments: []byte{3}, Data: []byte("abc")}
+	b := p.Bytes()
+	parsed, n, err := ParsePage(b)
+	assert.NoError(t, err)
+	assert.Equal(t, len(b), n)
+	assert.Equal(t, p.Data, parsed.Data)
+	assert.Equal(t, uint32(7), parsed.Serial)
+
+	b[len(b)-1] ^= 0xff
+	_, _, err = ParsePage(b)
+	assert.Error(t, err, "corrupt data fails the checksum")
+
+	_, _, err = ParsePage([]byte("<html>"))
+	assert.Equal(t, ErrNoCapture, err)
+}
+
+func TestLargePackets(t *testing.T) {
+	w := &pager{serial: 1}
+	big := bytes.Repeat([]byte{1}, 255*300)
+	w.add(big, 10)
+	w.add([]byte{2, 3}, 20)
+	w.flush(FlagEOS)
+
+	assert.Equal(t, 2, len(w.pages))
+	assert.Equal(t, int64(-1), w.pages[0].Granule, "no packet completes on the first page")
+	assert.Equal(t, FlagContinued|FlagEOS, w.pages[1].Flags)
+	assert.Equal(t, int64(20), w.pages[1].Granule)
+
+	packets := Packets(w.pages)
+	assert.Equal(t, 2, len(packets))
+	assert.Equal(t, big, packets[0])
+}
+
+func TestPacketSamples(t *testing.T) {
+	assert.Equal(t, 960, PacketSamples([]byte{0xf8}))        // CELT 20ms
+	assert.Equal(t, 120, PacketSamples([]byte{0x80}))        // CELT 2.5ms
+	assert.Equal(t, 2880, PacketSamples([]byte{0x18}))       // SILK 60ms
+	assert.Equal(t, 1920, PacketSamples([]byte{0xf9}))       // two frames
+	assert.Equal(t, 2880, PacketSamples([]byte{0xfb, 0x03})) // three frames, code 3
+	assert.Equal(t, 0, PacketSamples(nil))
+}
+
+func TestParseOpus(t *testing.T) {
+	b := opusFixture(42, 50, 100)
+	s, err := ParseOpus(b)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(42), s.Serial)
+	assert.Equal(t, 1, s.Channels())
+	assert.Equal(t, 312, s.PreSkip())
+	assert.Equal(t, 24000, s.InputSampleRate())
+	assert.Equal(t, 50, len(s.Packets))
+	assert.Equal(t, int64(50*960-100), s.Granule)
+
+	d, err := Duration(b)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(int64(50*960-100-312)*int64(time.Second)/48000), d)
+
+	pages, err := Parse(b)
+	assert.NoError(t, err)
+	assert.Equal(t, FlagBOS, pages[0].Flags)
+	assert.Equal(t, 1, len(pages[0].Segments), "OpusHead is alone on the first page")
+	assert.Equal(t, FlagEOS, pages[len(pages)-1].Flags&FlagEOS)
+}
+
+func TestPaginate(t *testing.T) {
+	b := opusFixture(1, 50, 0) // one second of audio.
+	out, err := Paginate(b, 100*time.Millisecond)
+	assert.NoError(t, err)
+
+	pages, err := Parse(out)
+	assert.NoError(t, err)
+	assert.Equal(t, 2+10, len(pages))
+	for i, p := range pages {
+		assert.Equal(t, uint32(i), p.Sequence)
+	}
+	assert.Equal(t, int64(5*960), pages[2].Granule)
+
+	d, err := Duration(out)
+	assert.NoError(t, err)
+	d0, _ := Duration(b)
+	assert.Equal(t, d0, d)
+}
+
+func TestConcat(t *testing.T) {
+	a := opusFixture(1, 50, 0)
+	b := opusFixture(2, 25, 200)
+
+	out, err := Concat(a, b)
+	assert.NoError(t, err)
+
+	s, err := ParseOpus(out)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), s.Serial)
+	assert.Equal(t, 75, len(s.Packets))
+	assert.Equal(t, int64(75*960-200), s.Granule)
+
+	pages, err := Parse(out)
+	assert.NoError(t, err)
+	for _, p := range pages {
+		assert.Equal(t, uint32(1), p.Serial, "a single logical stream")
+	}
+	// granule positions increase monotonically.
+	var last int64
+	for _, p := range pages {
+		if p.Granule >= 0 {
+			assert.True(t, p.Granule >= last)
+			last = p.Granule
+		}
+	}
+
+	_, err = Concat(a, []byte("OggS"))
+	assert.Error(t, err)
+}
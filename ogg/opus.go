@@ -0,0 +1,225 @@
+package ogg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// opusRate is the rate of Opus granule positions, independent of the input sample rate.
+const opusRate = 48000
+
+// DefaultPageDuration is the amount of audio written to each page by OpusStream.Bytes.
+const DefaultPageDuration = time.Second
+
+// OpusStream is a logical Ogg Opus stream, as specified by RFC 7845.
+type OpusStream struct {
+	Serial  uint32
+	Head    []byte   // OpusHead identification header packet.
+	Tags    []byte   // OpusTags comment header packet.
+	Packets [][]byte // audio packets.
+	// Granule is the granule position of the final page. It counts the pre-skip and excludes the padding that the
+	// encoder added to complete the final packet.
+	Granule int64
+}
+
+// ParseOpus decodes the Ogg Opus stream `b`. When `b` holds several chained streams, only the first is returned.
+func ParseOpus(b []byte) (*OpusStream, error) {
+	pages, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 || pages[0].Flags&FlagBOS == 0 {
+		return nil, fmt.Errorf("ogg: missing beginning of stream page")
+	}
+
+	serial := pages[0].Serial
+	var own []Page
+	for _, p := range pages {
+		if p.Serial == serial {
+			own = append(own, p)
+		}
+	}
+
+	packets := Packets(own)
+	if len(packets) < 2 {
+		return nil, fmt.Errorf("ogg: opus stream requires OpusHead and OpusTags packets")
+	}
+	if !bytes.HasPrefix(packets[0], []byte("OpusHead")) || len(packets[0]) < 19 {
+		return nil, fmt.Errorf("ogg: missing OpusHead packet")
+	}
+	if !bytes.HasPrefix(packets[1], []byte("OpusTags")) {
+		return nil, fmt.Errorf("ogg: missing OpusTags packet")
+	}
+
+	s := &OpusStream{Serial: serial, Head: packets[0], Tags: packets[1], Packets: packets[2:]}
+	for i := len(own) - 1; i >= 0; i-- {
+		if own[i].Granule >= 0 {
+			s.Granule = own[i].Granule
+			break
+		}
+	}
+	return s, nil
+}
+
+// Channels returns the channel count of the OpusHead packet.
+func (s *OpusStream) Channels() int {
+	return int(s.Head[9])
+}
+
+// PreSkip returns the number of samples, at 48kHz, to discard from the start of the decoded audio.
+func (s *OpusStream) PreSkip() int {
+	return int(binary.LittleEndian.Uint16(s.Head[10:12]))
+}
+
+// InputSampleRate returns the sample rate of the audio before it was encoded.
+func (s *OpusStream) InputSampleRate() int {
+	return int(binary.LittleEndian.Uint32(s.Head[12:16]))
+}
+
+// Samples returns the number of samples, at 48kHz, decoded from the audio packets, including pre-skip and padding.
+func (s *OpusStream) Samples() int64 {
+	var n int64
+	for _, p := range s.Packets {
+		n += int64(PacketSamples(p))
+	}
+	return n
+}
+
+// Duration returns the playback duration derived from the final granule position and the pre-skip.
+func (s *OpusStream) Duration() time.Duration {
+	n := s.Granule - int64(s.PreSkip())
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(n * int64(time.Second) / opusRate)
+}
+
+// Pages lays the stream out onto pages each holding at most `maxDuration` of audio, allowing a player to begin
+// playback after the first few pages arrive. The header packets are written to pages of their own, as required by
+// RFC 7845.
+func (s *OpusStream) Pages(maxDuration time.Duration) []Page {
+	limit := int64(maxDuration) * opusRate / int64(time.Second)
+	w := &pager{serial: s.Serial}
+
+	w.add(s.Head, 0)
+	w.flush(0)
+	w.add(s.Tags, 0)
+	w.flush(0)
+
+	// the final granule position trims the padding of the last packet; granules before it count full packets.
+	endTrim := s.Samples() - s.Granule
+	if endTrim < 0 {
+		endTrim = 0
+	}
+
+	var granule, pageSamples int64
+	for i, p := range s.Packets {
+		n := int64(PacketSamples(p))
+		granule += n
+		pageSamples += n
+		if i == len(s.Packets)-1 {
+			granule -= endTrim
+		}
+		w.add(p, granule)
+		if pageSamples >= limit && i < len(s.Packets)-1 {
+			w.flush(0)
+			pageSamples = 0
+		}
+	}
+	w.flush(FlagEOS)
+	return w.pages
+}
+
+// Bytes encodes the stream with pages of DefaultPageDuration.
+func (s *OpusStream) Bytes() []byte {
+	return encodePages(s.Pages(DefaultPageDuration))
+}
+
+func encodePages(pages []Page) []byte {
+	var b bytes.Buffer
+	for _, p := range pages {
+		b.Write(p.Bytes())
+	}
+	return b.Bytes()
+}
+
+// Paginate rewrites the Ogg Opus stream `b` with pages holding at most `maxDuration` of audio each.
+func Paginate(b []byte, maxDuration time.Duration) ([]byte, error) {
+	s, err := ParseOpus(b)
+	if err != nil {
+		return nil, err
+	}
+	return encodePages(s.Pages(maxDuration)), nil
+}
+
+// Duration returns the playback duration of the Ogg Opus stream `b`.
+func Duration(b []byte) (time.Duration, error) {
+	s, err := ParseOpus(b)
+	if err != nil {
+		return 0, err
+	}
+	return s.Duration(), nil
+}
+
+// Concat joins the Ogg Opus streams into a single logical stream, keeping the serial number, headers and pre-skip of
+// the first. Granule positions are recomputed across the joined packets. The encoder padding at the end of all but the
+// final stream, and the pre-skip of all but the first stream, remain part of the decoded audio.
+func Concat(streams ...[]byte) ([]byte, error) {
+	if len(streams) == 0 {
+		return nil, fmt.Errorf("ogg: nothing to concatenate")
+	}
+
+	var out *OpusStream
+	var offset int64
+	for i, b := range streams {
+		s, err := ParseOpus(b)
+		if err != nil {
+			return nil, fmt.Errorf("ogg: stream %d, %v", i, err)
+		}
+		if out == nil {
+			out = &OpusStream{Serial: s.Serial, Head: s.Head, Tags: s.Tags}
+		} else if s.Channels() != out.Channels() {
+			return nil, fmt.Errorf("ogg: stream %d has %d channel(s), expected %d", i, s.Channels(), out.Channels())
+		}
+
+		out.Packets = append(out.Packets, s.Packets...)
+		if i == len(streams)-1 {
+			out.Granule = offset + s.Granule
+		}
+		offset += s.Samples()
+	}
+	return out.Bytes(), nil
+}
+
+// PacketSamples returns the number of samples per channel, at 48kHz, encoded within the Opus packet `p`, as
+// described by its TOC byte (RFC 6716, section 3.1).
+func PacketSamples(p []byte) int {
+	if len(p) == 0 {
+		return 0
+	}
+	toc := p[0]
+	config := toc >> 3
+
+	var frameSize int
+	switch {
+	case config < 12: // SILK-only, 10, 20, 40 or 60ms.
+		frameSize = [...]int{480, 960, 1920, 2880}[config&3]
+	case config < 16: // Hybrid, 10 or 20ms.
+		frameSize = [...]int{480, 960}[config&1]
+	default: // CELT-only, 2.5, 5, 10 or 20ms.
+		frameSize = [...]int{120, 240, 480, 960}[config&3]
+	}
+
+	switch toc & 3 {
+	case 0:
+		return frameSize
+	case 1, 2:
+		return 2 * frameSize
+	}
+	if len(p) < 2 {
+		return 0
+	}
+	return int(p[1]&0x3f) * frameSize
+}
@@ -0,0 +1,215 @@
+/*
+Package ogg reads and writes Ogg pages and Ogg Opus streams, such as the `ogg-*-opus` outputs of the Azure
+text-to-speech API, without cgo. It computes durations from granule positions, concatenates several Opus streams
+into one logical stream and repaginates streams into short pages suitable for progressive playback in browsers.
+The `webm-*-opus` outputs use the Matroska container and are not handled by this package.
+*/
+package ogg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Page header flags.
+const (
+	FlagContinued byte = 0x01 // the first packet of the page continues a packet of the previous page.
+	FlagBOS       byte = 0x02 // first page of a logical stream.
+	FlagEOS       byte = 0x04 // last page of a logical stream.
+)
+
+// pageHeaderSize is the size of the fixed part of the page header, before the segment table.
+const pageHeaderSize = 27
+
+// maxSegments is the maximum number of lacing values within a page.
+const maxSegments = 255
+
+// ErrNoCapture is returned when the input does not start with the "OggS" capture pattern.
+var ErrNoCapture = errors.New("ogg: missing OggS capture pattern")
+
+// Page is a single Ogg page.
+type Page struct {
+	Flags    byte
+	Granule  int64 // granule position of the last packet completed on the page, -1 when no packet completes.
+	Serial   uint32
+	Sequence uint32
+	Segments []byte // lacing values.
+	Data     []byte
+}
+
+// ParsePage decodes the page at the start of `b`, verifying its checksum, and returns the page and its encoded size.
+func ParsePage(b []byte) (Page, int, error) {
+	if len(b) < 4 || string(b[0:4]) != "OggS" {
+		return Page{}, 0, ErrNoCapture
+	}
+	if len(b) < pageHeaderSize {
+		return Page{}, 0, fmt.Errorf("ogg: truncated page header")
+	}
+	if b[4] != 0 {
+		return Page{}, 0, fmt.Errorf("ogg: unsupported stream structure version %d", b[4])
+	}
+
+	n := int(b[26])
+	if len(b) < pageHeaderSize+n {
+		return Page{}, 0, fmt.Errorf("ogg: truncated segment table")
+	}
+	segments := b[pageHeaderSize : pageHeaderSize+n]
+	size := pageHeaderSize + n
+	for _, s := range segments {
+		size += int(s)
+	}
+	if len(b) < size {
+		return Page{}, 0, fmt.Errorf("ogg: truncated page, expected %d bytes", size)
+	}
+
+	want := binary.LittleEndian.Uint32(b[22:26])
+	if got := checksum(b[:size]); got != want {
+		return Page{}, 0, fmt.Errorf("ogg: checksum mismatch, 0x%08x != 0x%08x", got, want)
+	}
+
+	return Page{
+		Flags:    b[5],
+		Granule:  int64(binary.LittleEndian.Uint64(b[6:14])),
+		Serial:   binary.LittleEndian.Uint32(b[14:18]),
+		Sequence: binary.LittleEndian.Uint32(b[18:22]),
+		Segments: segments,
+		Data:     b[pageHeaderSize+n : size],
+	}, size, nil
+}
+
+// Parse decodes all pages of `b`.
+func Parse(b []byte) ([]Page, error) {
+	var pages []Page
+	for len(b) > 0 {
+		p, n, err := ParsePage(b)
+		if err != nil {
+			return nil, fmt.Errorf("ogg: page %d, %v", len(pages), err)
+		}
+		pages = append(pages, p)
+		b = b[n:]
+	}
+	return pages, nil
+}
+
+// Bytes encodes the page, computing its checksum.
+func (p Page) Bytes() []byte {
+	b := make([]byte, pageHeaderSize+len(p.Segments)+len(p.Data))
+	copy(b, "OggS")
+	b[5] = p.Flags
+	binary.LittleEndian.PutUint64(b[6:14], uint64(p.Granule))
+	binary.LittleEndian.PutUint32(b[14:18], p.Serial)
+	binary.LittleEndian.PutUint32(b[18:22], p.Sequence)
+	b[26] = byte(len(p.Segments))
+	copy(b[pageHeaderSize:], p.Segments)
+	copy(b[pageHeaderSize+len(p.Segments):], p.Data)
+	binary.LittleEndian.PutUint32(b[22:26], checksum(b))
+	return b
+}
+
+// Packets reassembles the packets carried by `pages`. A packet left incomplete by the final page is dropped.
+func Packets(pages []Page) [][]byte {
+	var packets [][]byte
+	var cur []byte
+	for _, p := range pages {
+		offset := 0
+		for _, s := range p.Segments {
+			cur = append(cur, p.Data[offset:offset+int(s)]...)
+			offset += int(s)
+			if s < 255 {
+				packets = append(packets, cur)
+				cur = nil
+			}
+		}
+	}
+	return packets
+}
+
+// pager lays out packets onto pages.
+type pager struct {
+	serial    uint32
+	pages     []Page
+	cur       Page
+	completed bool  // a packet completed on the current page.
+	granule   int64 // granule position of the last packet completed.
+}
+
+// add appends `packet`, completing at granule position `granule`, to the current page. Pages are emitted when the
+// segment table is full, with packets spanning several pages as needed.
+func (w *pager) add(packet []byte, granule int64) {
+	for first := true; ; first = false {
+		if len(w.cur.Segments) == maxSegments {
+			w.emit(0)
+			if !first {
+				w.cur.Flags |= FlagContinued
+			}
+		}
+		n := len(packet)
+		if n > 255 {
+			n = 255
+		}
+		w.cur.Segments = append(w.cur.Segments, byte(n))
+		w.cur.Data = append(w.cur.Data, packet[:n]...)
+		packet = packet[n:]
+		if n < 255 {
+			break
+		}
+	}
+	w.completed = true
+	w.granule = granule
+}
+
+// emit writes the current page with the additional `flags`, and starts a new page.
+func (w *pager) emit(flags byte) {
+	p := w.cur
+	p.Flags |= flags
+	p.Serial = w.serial
+	p.Sequence = uint32(len(w.pages))
+	// pages without a completed packet carry -1, except for an empty final page which repeats the last position.
+	p.Granule = -1
+	if w.completed || len(p.Segments) == 0 {
+		p.Granule = w.granule
+	}
+	if p.Sequence == 0 {
+		p.Flags |= FlagBOS
+	}
+	w.pages = append(w.pages, p)
+	w.cur = Page{}
+	w.completed = false
+}
+
+// flush emits the current page if it holds any data.
+func (w *pager) flush(flags byte) {
+	if len(w.cur.Segments) > 0 || flags&FlagEOS != 0 {
+		w.emit(flags)
+	}
+}
+
+// checksumTable is the lookup table for the Ogg CRC-32, polynomial 0x04c11db7 without reflection.
+var checksumTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+// checksum returns the CRC of an encoded page, treating the checksum field as zero.
+func checksum(b []byte) uint32 {
+	var crc uint32
+	for i, c := range b {
+		if i >= 22 && i < 26 {
+			c = 0
+		}
+		crc = crc<<8 ^ checksumTable[byte(crc>>24)^c]
+	}
+	return crc
+}
@@ -0,0 +1,202 @@
+package pcm
+
+import "math"
+
+// Loudness measurement as specified by ITU-R BS.1770-4 and EBU R128.
+const (
+	loudnessBlock    = 0.4 // gating block length in seconds.
+	loudnessStep     = 0.1 // gating blocks overlap by 75%.
+	absoluteGate     = -70 // LUFS.
+	relativeGate     = -10 // LU below the absolutely gated loudness.
+	truePeakUpsample = 4   // oversampling factor of the true peak meter.
+	loudnessOffset   = -0.691
+)
+
+// Loudness is the result of a loudness measurement.
+type Loudness struct {
+	Integrated float64 // gated integrated loudness in LUFS, -Inf for silence.
+	TruePeak   float64 // maximum inter-sample peak level in dBTP, -Inf for silence.
+}
+
+// biquad is a second order IIR filter in direct form I.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the two stages of the K-weighting filter, a high shelf modelling the acoustic effect of the head
+// followed by a high pass, with the coefficients derived for `rate` from the analogue prototypes of BS.1770.
+func kWeighting(rate int) (*biquad, *biquad) {
+	const (
+		shelfFreq = 1681.974450955533
+		shelfGain = 3.999843853973347
+		shelfQ    = 0.7071752369554196
+		passFreq  = 38.13547087602444
+		passQ     = 0.5003270373238773
+	)
+
+	k := math.Tan(math.Pi * shelfFreq / float64(rate))
+	vh := math.Pow(10, shelfGain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/shelfQ + k*k
+	shelf := &biquad{
+		b0: (vh + vb*k/shelfQ + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/shelfQ + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/shelfQ + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * passFreq / float64(rate))
+	a0 = 1 + k/passQ + k*k
+	pass := &biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/passQ + k*k) / a0,
+	}
+	return shelf, pass
+}
+
+// MeasureLoudness returns the integrated loudness and true peak of the interleaved `samples` at sample rate `rate`.
+// All channels are weighted equally, which matches BS.1770 for mono and stereo audio. Audio shorter than a gating
+// block is measured as a single block.
+func MeasureLoudness(samples []float64, channels, rate int) Loudness {
+	if channels < 1 {
+		channels = 1
+	}
+	frames := len(samples) / channels
+	split := Deinterleave(samples, channels)
+
+	// mean square of the K-weighted signal, summed across channels, per 100ms step.
+	step := int(loudnessStep * float64(rate))
+	if step < 1 {
+		step = 1
+	}
+	power := make([]float64, (frames+step-1)/step)
+	for _, ch := range split {
+		shelf, pass := kWeighting(rate)
+		for i, s := range ch {
+			y := pass.process(shelf.process(s))
+			power[i/step] += y * y
+		}
+	}
+
+	// gating blocks of 400ms span four consecutive steps.
+	span := int(math.Round(loudnessBlock / loudnessStep))
+	var blocks []float64
+	for i := 0; i+span <= len(power); i++ {
+		var sum float64
+		for _, p := range power[i : i+span] {
+			sum += p
+		}
+		blocks = append(blocks, sum/float64(span*step))
+	}
+	if len(blocks) == 0 && frames > 0 {
+		var sum float64
+		for _, p := range power {
+			sum += p
+		}
+		blocks = append(blocks, sum/float64(frames))
+	}
+
+	return Loudness{Integrated: gatedLoudness(blocks), TruePeak: truePeak(split)}
+}
+
+func blockLoudness(power float64) float64 {
+	return loudnessOffset + 10*math.Log10(power)
+}
+
+// gatedLoudness applies the absolute and relative gates to the block powers and returns the integrated loudness.
+func gatedLoudness(blocks []float64) float64 {
+	mean := func(gate float64) (float64, int) {
+		var sum float64
+		n := 0
+		for _, p := range blocks {
+			if blockLoudness(p) > gate {
+				sum += p
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, 0
+		}
+		return sum / float64(n), n
+	}
+
+	p, n := mean(absoluteGate)
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	p, n = mean(blockLoudness(p) + relativeGate)
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(p)
+}
+
+// truePeakTaps is the number of input samples on either side of an interpolated sample used by the true peak meter.
+const truePeakTaps = 12
+
+// truePeakKernel holds the interpolation filter of each oversampling phase, a windowed sinc.
+var truePeakKernel = func() [truePeakUpsample][2 * truePeakTaps]float64 {
+	var k [truePeakUpsample][2 * truePeakTaps]float64
+	for p := range k {
+		for j := range k[p] {
+			x := float64(p)/truePeakUpsample + float64(truePeakTaps-1-j)
+			k[p][j] = sinc(x) * blackman(x/truePeakTaps)
+		}
+	}
+	return k
+}()
+
+// truePeak returns the peak level of the channels oversampled by truePeakUpsample.
+func truePeak(channels [][]float64) float64 {
+	var peak float64
+	for _, ch := range channels {
+		for i := range ch {
+			for p := range truePeakKernel {
+				// interpolate at i + p/truePeakUpsample from the surrounding input samples.
+				var s float64
+				for j, c := range truePeakKernel[p] {
+					if k := i - truePeakTaps + 1 + j; k >= 0 && k < len(ch) {
+						s += ch[k] * c
+					}
+				}
+				if a := math.Abs(s); a > peak {
+					peak = a
+				}
+			}
+		}
+	}
+	return Decibels(peak)
+}
+
+// Gain scales `samples` in place by `db` decibels.
+func Gain(samples []float64, db float64) {
+	g := Amplitude(db)
+	for i := range samples {
+		samples[i] *= g
+	}
+}
+
+// NormalizationGain returns the gain in dB bringing audio of loudness `l` to `target` LUFS, reduced as needed to keep
+// the true peak at or below `maxTruePeak` dBTP. Zero is returned for silence.
+func NormalizationGain(l Loudness, target, maxTruePeak float64) float64 {
+	if math.IsInf(l.Integrated, -1) {
+		return 0
+	}
+	gain := target - l.Integrated
+	if headroom := maxTruePeak - l.TruePeak; gain > headroom {
+		gain = headroom
+	}
+	return gain
+}
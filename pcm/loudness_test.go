@@ -0,0 +1,44 @@
+package pcm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeasureLoudness(t *testing.T) {
+	for _, rate := range []int{48000, 24000, 16000} {
+		// a 997Hz tone at -20dBFS reads -23 LUFS on a single channel, the K-weighting adds 0.69dB at 1kHz.
+		l := MeasureLoudness(sine(997, rate, 5*rate, 0.1), 1, rate)
+		assert.InDelta(t, -23.0, l.Integrated, 0.1, "rate %d", rate)
+		assert.InDelta(t, -20.0, l.TruePeak, 0.1, "rate %d", rate)
+	}
+
+	// both channels of a stereo signal contribute.
+	tone := sine(997, 48000, 48000*2, 0.1)
+	l := MeasureLoudness(Interleave([][]float64{tone, tone}), 2, 48000)
+	assert.InDelta(t, -20.0, l.Integrated, 0.1)
+
+	// silence is gated out, leaving the loudness of the tone; blocks straddling the edges of the tones still count.
+	gapped := append(append(sine(997, 48000, 48000*2, 0.1), Silence(48000*4, 1)...), sine(997, 48000, 48000*2, 0.1)...)
+	assert.InDelta(t, -23.0, MeasureLoudness(gapped, 1, 48000).Integrated, 0.5)
+
+	l = MeasureLoudness(Silence(48000, 1), 1, 48000)
+	assert.True(t, math.IsInf(l.Integrated, -1))
+	assert.Equal(t, 0.0, NormalizationGain(l, -23, -1))
+
+	// audio shorter than a gating block is still measured.
+	assert.InDelta(t, -23.0, MeasureLoudness(sine(997, 48000, 9600, 0.1), 1, 48000).Integrated, 0.5)
+}
+
+func TestNormalizationGain(t *testing.T) {
+	assert.Equal(t, 7.0, NormalizationGain(Loudness{Integrated: -30, TruePeak: -12}, -23, -1))
+	assert.Equal(t, 3.0, NormalizationGain(Loudness{Integrated: -30, TruePeak: -4}, -23, -1), "limited by the true peak")
+	assert.Equal(t, -5.0, NormalizationGain(Loudness{Integrated: -18, TruePeak: -4}, -23, -1))
+
+	s := []float64{0.5, -0.25}
+	Gain(s, -6.0206)
+	assert.InDelta(t, 0.25, s[0], 1e-4)
+	assert.InDelta(t, -0.125, s[1], 1e-4)
+}
@@ -0,0 +1,120 @@
+package pcm
+
+import "math"
+
+// Remix converts interleaved `samples` from `from` channels to `to` channels. Mono is copied to every output channel,
+// and multi-channel audio is averaged down to mono before being spread across the output channels.
+func Remix(samples []float64, from, to int) []float64 {
+	if from == to || from < 1 || to < 1 {
+		return append([]float64(nil), samples...)
+	}
+	frames := len(samples) / from
+	out := make([]float64, frames*to)
+	for i := 0; i < frames; i++ {
+		var sum float64
+		for _, s := range samples[i*from : (i+1)*from] {
+			sum += s
+		}
+		for c := 0; c < to; c++ {
+			out[i*to+c] = sum / float64(from)
+		}
+	}
+	return out
+}
+
+// Fade applies a linear fade in over the first `in` frames and a linear fade out over the last `out` frames of the
+// interleaved `samples`, in place.
+func Fade(samples []float64, channels, in, out int) {
+	if channels < 1 {
+		channels = 1
+	}
+	frames := len(samples) / channels
+	for i := 0; i < frames; i++ {
+		g := 1.0
+		if i < in {
+			g = float64(i) / float64(in)
+		}
+		if r := frames - 1 - i; r < out {
+			g = math.Min(g, float64(r)/float64(out))
+		}
+		for c := 0; c < channels; c++ {
+			samples[i*channels+c] *= g
+		}
+	}
+}
+
+// Mix adds `src` onto `dst` in place, from the start of `dst`. Samples of `src` past the end of `dst` are dropped.
+func Mix(dst, src []float64) {
+	for i := 0; i < len(dst) && i < len(src); i++ {
+		dst[i] += src[i]
+	}
+}
+
+// Envelope returns the peak envelope of each frame of the interleaved `samples`, rising with time constant `attack`
+// frames and falling with time constant `release` frames.
+func Envelope(samples []float64, channels int, attack, release float64) []float64 {
+	if channels < 1 {
+		channels = 1
+	}
+	up, down := smoothing(attack), smoothing(release)
+
+	env := make([]float64, len(samples)/channels)
+	var level float64
+	for i := range env {
+		var peak float64
+		for _, s := range samples[i*channels : (i+1)*channels] {
+			peak = math.Max(peak, math.Abs(s))
+		}
+		k := down
+		if peak > level {
+			k = up
+		}
+		level = k*level + (1-k)*peak
+		env[i] = level
+	}
+	return env
+}
+
+// Duck returns the per frame gain of a side chain compressor that attenuates by `depth` dB whenever the side chain
+// `envelope` exceeds `threshold` dBFS. The gain moves towards its target with time constants of `attack` and
+// `release` frames, avoiding audible steps.
+func Duck(envelope []float64, threshold, depth, attack, release float64) []float64 {
+	limit := Amplitude(threshold)
+	ducked := Amplitude(-math.Abs(depth))
+	down, up := smoothing(attack), smoothing(release)
+
+	gain := make([]float64, len(envelope))
+	g := 1.0
+	for i, e := range envelope {
+		target, k := 1.0, up
+		if e >= limit {
+			target, k = ducked, down
+		}
+		g = k*g + (1-k)*target
+		gain[i] = g
+	}
+	return gain
+}
+
+// smoothing returns the coefficient of a one pole filter with a time constant of `frames`.
+func smoothing(frames float64) float64 {
+	if frames <= 0 {
+		return 0
+	}
+	return math.Exp(-1 / frames)
+}
+
+// Pan places the mono `samples` within the stereo field using constant power panning, returning interleaved stereo.
+// `position` ranges from -1, fully left, through 0, centre, to 1, fully right.
+func Pan(samples []float64, position float64) []float64 {
+	position = math.Max(-1, math.Min(1, position))
+	angle := (position + 1) * math.Pi / 4
+	left, right := math.Cos(angle), math.Sin(angle)
+
+	out := make([]float64, 2*len(samples))
+	for i, s := range samples {
+		out[2*i] = s * left
+		out[2*i+1] = s * right
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package pcm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemix(t *testing.T) {
+	assert.Equal(t, []float64{0.5, 0.5, -0.25, -0.25}, Remix([]float64{0.5, -0.25}, 1, 2))
+	assert.Equal(t, []float64{0.25, 0.5}, Remix([]float64{0.5, 0, 0.25, 0.75}, 2, 1))
+}
+
+func TestFade(t *testing.T) {
+	s := []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	Fade(s, 1, 4, 2)
+	assert.Equal(t, []float64{0, 0.25, 0.5, 0.75, 1, 1, 1, 1, 0.5, 0}, s)
+}
+
+func TestMix(t *testing.T) {
+	dst := []float64{0.25, 0.25, 0.25}
+	Mix(dst, []float64{0.5, -0.25, 0, 1})
+	assert.Equal(t, []float64{0.75, 0, 0.25}, dst)
+}
+
+func TestDuck(t *testing.T) {
+	// half a second of silence, a second of speech, then a second of silence at 1kHz.
+	speech := append(append(Silence(500, 1), sine(100, 1000, 1000, 0.5)...), Silence(1000, 1)...)
+	env := Envelope(speech, 1, 1, 20)
+	gain := Duck(env, -40, 12, 10, 100)
+
+	assert.Equal(t, 1.0, gain[499], "no ducking before the speech")
+	assert.InDelta(t, Amplitude(-12), gain[1200], 1e-3, "fully ducked during the speech")
+	assert.True(t, gain[1650] > gain[1200] && gain[1650] < 1, "releasing after the speech")
+	assert.InDelta(t, 1.0, gain[2499], 1e-3, "recovered")
+}
+
+func TestPan(t *testing.T) {
+	assert.Equal(t, []float64{0.5, 0, -0.5, 0}, Pan([]float64{0.5, -0.5}, -1))
+	centre := Pan([]float64{1}, 0)
+	assert.InDelta(t, Decibels(centre[0]), -3.01, 0.01)
+	assert.InDelta(t, centre[0], centre[1], 1e-12)
+	right := Pan([]float64{1}, 2)
+	assert.InDelta(t, 0, right[0], 1e-12)
+	assert.InDelta(t, 1, right[1], 1e-12)
+}
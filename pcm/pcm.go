@@ -0,0 +1,39 @@
+/*
+Package pcm provides signal processing for linear PCM audio, such as the decoded samples of the `riff-*` and `raw-*`
+outputs of the Azure text-to-speech API. Samples are float64 values scaled to the range [-1, 1); multi-channel audio is
+interleaved.
+*/
+package pcm
+
+// Deinterleave splits interleaved `samples` into one slice per channel.
+func Deinterleave(samples []float64, channels int) [][]float64 {
+	out := make([][]float64, channels)
+	n := len(samples) / channels
+	for c := range out {
+		out[c] = make([]float64, n)
+		for i := 0; i < n; i++ {
+			out[c][i] = samples[i*channels+c]
+		}
+	}
+	return out
+}
+
+// Interleave joins per channel samples into a single interleaved slice. The output is as long as the shortest channel.
+func Interleave(channels [][]float64) []float64 {
+	if len(channels) == 0 {
+		return nil
+	}
+	n := len(channels[0])
+	for _, c := range channels {
+		if len(c) < n {
+			n = len(c)
+		}
+	}
+	out := make([]float64, n*len(channels))
+	for i := 0; i < n; i++ {
+		for c := range channels {
+			out[i*len(channels)+c] = channels[c][i]
+		}
+	}
+	return out
+}
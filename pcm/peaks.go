@@ -0,0 +1,27 @@
+package pcm
+
+// Peaks returns the minimum and maximum sample of each block of `size` frames of the interleaved `samples`, taken
+// across all channels. The final block may be shorter.
+func Peaks(samples []float64, channels, size int) (min, max []float64) {
+	if channels < 1 {
+		channels = 1
+	}
+	if size < 1 {
+		size = 1
+	}
+	frames := len(samples) / channels
+	blocks := (frames + size - 1) / size
+	min, max = make([]float64, blocks), make([]float64, blocks)
+	for i := 0; i < frames; i++ {
+		b := i / size
+		for _, s := range samples[i*channels : (i+1)*channels] {
+			if s < min[b] {
+				min[b] = s
+			}
+			if s > max[b] {
+				max[b] = s
+			}
+		}
+	}
+	return min, max
+}
@@ -0,0 +1,16 @@
+package pcm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeaks(t *testing.T) {
+	min, max := Peaks([]float64{0.1, -0.2, 0.5, 0.3, -0.9, 0, 0.25, 0.1}, 2, 3)
+	assert.Equal(t, []float64{-0.9, 0}, min)
+	assert.Equal(t, []float64{0.5, 0.25}, max)
+
+	min, max = Peaks(nil, 1, 100)
+	assert.Equal(t, 0, len(min)+len(max))
+}
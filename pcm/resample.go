@@ -0,0 +1,111 @@
+package pcm
+
+import "math"
+
+// resampleTaps is the number of zero crossings of the interpolation kernel on either side of the output sample.
+// Higher values give a steeper anti-aliasing filter at the cost of speed.
+const resampleTaps = 32
+
+// resampleRolloff places the filter cutoff just below the Nyquist frequency of the lower of the two sample rates.
+const resampleRolloff = 0.95
+
+// resampleOversample is the number of kernel values tabulated per input sample; the kernel is linearly interpolated
+// between them.
+const resampleOversample = 512
+
+// resampleKernel tabulates the Blackman windowed sinc kernel for a conversion, so that it is evaluated once per
+// conversion rather than for every tap of every output sample.
+type resampleKernel struct {
+	ratio     float64   // output samples per input sample.
+	halfWidth float64   // kernel half width in input samples.
+	table     []float64 // kernel values at multiples of 1/resampleOversample input samples from its center.
+}
+
+func newResampleKernel(from, to int) *resampleKernel {
+	ratio := float64(to) / float64(from)
+	cutoff := resampleRolloff * math.Min(1, ratio) // normalised to the input Nyquist frequency.
+	halfWidth := float64(resampleTaps) / cutoff
+
+	// one more entry past the half width, where the window is zero, so that interpolation never reads beyond the table.
+	table := make([]float64, int(halfWidth*resampleOversample)+2)
+	for j := range table {
+		x := float64(j) / resampleOversample
+		table[j] = cutoff * sinc(cutoff*x) * blackman(x/halfWidth)
+	}
+	return &resampleKernel{ratio: ratio, halfWidth: halfWidth, table: table}
+}
+
+// at returns the kernel value at `x` input samples from its center.
+func (k *resampleKernel) at(x float64) float64 {
+	pos := math.Abs(x) * resampleOversample
+	j := int(pos)
+	if j >= len(k.table)-1 {
+		return 0
+	}
+	frac := pos - float64(j)
+	return k.table[j] + frac*(k.table[j+1]-k.table[j])
+}
+
+// Resample converts mono `samples` from the sample rate `from` to `to` using band-limited interpolation with a
+// Blackman windowed sinc kernel. When downsampling, content above the new Nyquist frequency is filtered out.
+func Resample(samples []float64, from, to int) []float64 {
+	if from == to || from <= 0 || to <= 0 {
+		return append([]float64(nil), samples...)
+	}
+	return newResampleKernel(from, to).resample(samples, from, to)
+}
+
+func (k *resampleKernel) resample(samples []float64, from, to int) []float64 {
+	n := int(int64(len(samples)) * int64(to) / int64(from))
+	out := make([]float64, n)
+	for i := range out {
+		t := float64(i) / k.ratio // position of the output sample on the input time axis.
+		lo := int(math.Ceil(t - k.halfWidth))
+		hi := int(math.Floor(t + k.halfWidth))
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(samples)-1 {
+			hi = len(samples) - 1
+		}
+
+		var sum float64
+		for j := lo; j <= hi; j++ {
+			sum += samples[j] * k.at(t-float64(j))
+		}
+		out[i] = sum
+	}
+	return out
+}
+
+// ResampleInterleaved converts interleaved multi-channel `samples` from the sample rate `from` to `to`.
+func ResampleInterleaved(samples []float64, channels, from, to int) []float64 {
+	if channels <= 1 {
+		return Resample(samples, from, to)
+	}
+	if from == to || from <= 0 || to <= 0 {
+		return append([]float64(nil), samples...)
+	}
+	k := newResampleKernel(from, to)
+	split := Deinterleave(samples, channels)
+	for c := range split {
+		split[c] = k.resample(split[c], from, to)
+	}
+	return Interleave(split)
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// blackman evaluates the Blackman window over u in [-1, 1].
+func blackman(u float64) float64 {
+	if u <= -1 || u >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*u) + 0.08*math.Cos(2*math.Pi*u)
+}
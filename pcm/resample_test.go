@@ -0,0 +1,71 @@
+package pcm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sine(freq float64, rate, n int, amplitude float64) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate))
+	}
+	return s
+}
+
+// rmsError returns the RMS difference of `a` and `b`, ignoring `edge` samples at either end.
+func rmsError(a, b []float64, edge int) float64 {
+	var sum float64
+	n := 0
+	for i := edge; i < len(a)-edge && i < len(b)-edge; i++ {
+		d := a[i] - b[i]
+		sum += d * d
+		n++
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct{ from, to int }{
+		{24000, 16000},
+		{16000, 24000},
+		{24000, 8000},
+		{22050, 48000},
+		{48000, 44100},
+	}
+	for _, tc := range tests {
+		in := sine(440, tc.from, tc.from/2, 0.5)
+		out := Resample(in, tc.from, tc.to)
+		assert.Equal(t, tc.to/2, len(out), "%d -> %d", tc.from, tc.to)
+		assert.True(t, rmsError(out, sine(440, tc.to, tc.to/2, 0.5), 200) < 0.002, "%d -> %d", tc.from, tc.to)
+	}
+}
+
+func TestResampleKernel(t *testing.T) {
+	// the tabulated kernel follows the windowed sinc it approximates.
+	k := newResampleKernel(24000, 8000)
+	cutoff := resampleRolloff / 3
+	for x := -k.halfWidth - 1; x <= k.halfWidth+1; x += 0.0123 {
+		want := cutoff * sinc(cutoff*x) * blackman(x/k.halfWidth)
+		assert.InDelta(t, want, k.at(x), 1e-6, "x = %f", x)
+	}
+}
+
+func TestResampleAntiAliasing(t *testing.T) {
+	// a 6kHz tone is above the 4kHz Nyquist frequency of 8kHz audio and must be removed.
+	out := Resample(sine(6000, 24000, 24000, 0.5), 24000, 8000)
+	assert.True(t, rmsError(out, make([]float64, len(out)), 200) < 0.005)
+}
+
+func TestResampleInterleaved(t *testing.T) {
+	left, right := sine(440, 16000, 1600, 0.5), sine(440, 16000, 1600, -0.25)
+	out := Deinterleave(ResampleInterleaved(Interleave([][]float64{left, right}), 2, 16000, 8000), 2)
+	assert.Equal(t, 800, len(out[0]))
+	assert.True(t, rmsError(out[0], sine(440, 8000, 800, 0.5), 100) < 0.002)
+	assert.True(t, rmsError(out[1], sine(440, 8000, 800, -0.25), 100) < 0.002)
+
+	same := Resample([]float64{1, 2}, 8000, 8000)
+	assert.Equal(t, []float64{1, 2}, same)
+}
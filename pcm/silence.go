@@ -0,0 +1,47 @@
+package pcm
+
+import "math"
+
+// Amplitude converts a level in dBFS to a linear amplitude.
+func Amplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// Decibels converts a linear amplitude to a level in dBFS.
+func Decibels(amplitude float64) float64 {
+	return 20 * math.Log10(math.Abs(amplitude))
+}
+
+// SilentEdges returns the number of leading and trailing frames of the interleaved `samples` whose peak amplitude,
+// across all channels, stays below `threshold` dBFS.
+func SilentEdges(samples []float64, channels int, threshold float64) (lead, trail int) {
+	if channels < 1 {
+		channels = 1
+	}
+	limit := Amplitude(threshold)
+	frames := len(samples) / channels
+	silent := func(i int) bool {
+		for _, s := range samples[i*channels : (i+1)*channels] {
+			if math.Abs(s) >= limit {
+				return false
+			}
+		}
+		return true
+	}
+
+	for lead < frames && silent(lead) {
+		lead++
+	}
+	if lead == frames {
+		return lead, 0
+	}
+	for trail < frames-lead && silent(frames-1-trail) {
+		trail++
+	}
+	return lead, trail
+}
+
+// Silence returns `frames` frames of silence with `channels` interleaved channels.
+func Silence(frames, channels int) []float64 {
+	return make([]float64, frames*channels)
+}
@@ -0,0 +1,26 @@
+package pcm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSilentEdges(t *testing.T) {
+	samples := append(Silence(100, 2), sine(440, 8000, 400, 0.5)...)
+	samples = append(samples, Silence(50, 2)...)
+	samples[1] = Amplitude(-60) // below the threshold.
+
+	lead, trail := SilentEdges(samples, 2, -50)
+	assert.Equal(t, 100, lead)
+	assert.Equal(t, 50, trail)
+
+	lead, trail = SilentEdges(Silence(10, 1), 1, -50)
+	assert.Equal(t, 10, lead)
+	assert.Equal(t, 0, trail)
+}
+
+func TestDecibels(t *testing.T) {
+	assert.InDelta(t, 0.1, Amplitude(-20), 1e-12)
+	assert.InDelta(t, -6.0206, Decibels(-0.5), 1e-4)
+}
@@ -0,0 +1,255 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultPoolFailureThreshold is the number of consecutive failures ejecting a client from a Pool unless configured
+// otherwise.
+const DefaultPoolFailureThreshold = 3
+
+// DefaultPoolEjectionTime is how long a Pool keeps an ejected client out of rotation before probing it again, unless
+// configured otherwise.
+const DefaultPoolEjectionTime = 30 * time.Second
+
+// DefaultPoolAttemptTimeout bounds each attempt of a Pool unless configured otherwise, leaving time to fail over when
+// a region hangs.
+const DefaultPoolAttemptTimeout = 10 * time.Second
+
+// poolLatencyWeight is the weight of the latest request within the moving average of the latency of a client.
+const poolLatencyWeight = 0.3
+
+// PoolOptions configures NewPool. The zero value uses the defaults above and tries every client once per request.
+type PoolOptions struct {
+	FailureThreshold int           // consecutive failures ejecting a client.
+	EjectionTime     time.Duration // time an ejected client is kept out of rotation before it is probed.
+	AttemptTimeout   time.Duration // bound of each attempt, within the deadline of the request.
+	MaxAttempts      int           // clients tried per request.
+}
+
+// Pool spreads synthesis requests over several clients, typically bound to different regions and/or keys. Requests go
+// to the healthy client with the lowest latency and fail over to the next client on server errors, throttling,
+// timeouts and authentication errors. Clients failing repeatedly are ejected by a circuit breaker, and probed with a
+// single request once the ejection time has passed.
+type Pool struct {
+	members []*poolMember
+	opts    PoolOptions
+}
+
+type poolMember struct {
+	client  *AzureCSTextToSpeech
+	breaker *CircuitBreaker
+
+	mu      sync.Mutex
+	latency time.Duration // moving average, zero until the first success.
+	served  int64
+	failed  int64
+}
+
+// PoolMemberHealth captures the health of a client of a Pool.
+type PoolMemberHealth struct {
+	Endpoint string // text-to-speech endpoint of the client.
+	State    BreakerState
+	Latency  time.Duration // moving average of the latency of successful requests.
+	Served   int64
+	Failed   int64
+}
+
+// NewPool returns a Pool spreading requests over `clients`.
+func NewPool(clients []*AzureCSTextToSpeech, opts PoolOptions) (*Pool, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("pool requires at least one client")
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = DefaultPoolFailureThreshold
+	}
+	if opts.EjectionTime <= 0 {
+		opts.EjectionTime = DefaultPoolEjectionTime
+	}
+	if opts.AttemptTimeout <= 0 {
+		opts.AttemptTimeout = DefaultPoolAttemptTimeout
+	}
+	if opts.MaxAttempts <= 0 || opts.MaxAttempts > len(clients) {
+		opts.MaxAttempts = len(clients)
+	}
+
+	p := &Pool{opts: opts}
+	for _, c := range clients {
+		p.members = append(p.members, &poolMember{client: c, breaker: NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: opts.FailureThreshold, OpenTimeout: opts.EjectionTime})})
+	}
+	return p, nil
+}
+
+// Health returns the health of every client, in the order given to NewPool.
+func (p *Pool) Health() []PoolMemberHealth {
+	health := make([]PoolMemberHealth, len(p.members))
+	for i, m := range p.members {
+		m.mu.Lock()
+		health[i] = PoolMemberHealth{
+			Endpoint: m.client.textToSpeechURL,
+			State:    m.breaker.State(),
+			Latency:  m.latency,
+			Served:   m.served,
+			Failed:   m.failed,
+		}
+		m.mu.Unlock()
+	}
+	return health
+}
+
+// SynthesizeWithContext behaves as AzureCSTextToSpeech.SynthesizeWithContext on the clients of the pool.
+func (p *Pool) SynthesizeWithContext(ctx context.Context, speechText string, locale Locale, gender Gender, audioOutput AudioOutput) ([]byte, error) {
+	result, err := p.SynthesizeResultWithContext(ctx, speechText, locale, gender, audioOutput)
+	if err != nil {
+		return nil, err
+	}
+	return result.Audio, nil
+}
+
+// SynthesizeResultWithContext behaves as AzureCSTextToSpeech.SynthesizeResultWithContext on the clients of the pool.
+func (p *Pool) SynthesizeResultWithContext(ctx context.Context, speechText string, locale Locale, gender Gender, audioOutput AudioOutput) (*SynthesisResult, error) {
+	return p.do(ctx, func(ctx context.Context, az *AzureCSTextToSpeech) (*SynthesisResult, error) {
+		return az.SynthesizeResultWithContext(ctx, speechText, locale, gender, audioOutput)
+	})
+}
+
+// SynthesizeVoiceResultWithContext behaves as AzureCSTextToSpeech.SynthesizeVoiceResultWithContext on the clients of
+// the pool.
+func (p *Pool) SynthesizeVoiceResultWithContext(ctx context.Context, speechText string, voiceName string, audioOutput AudioOutput) (*SynthesisResult, error) {
+	return p.do(ctx, func(ctx context.Context, az *AzureCSTextToSpeech) (*SynthesisResult, error) {
+		return az.SynthesizeVoiceResultWithContext(ctx, speechText, voiceName, audioOutput)
+	})
+}
+
+// candidates returns the members in the order they are tried: clients due a probe first, so that recovered clients
+// return to rotation, then healthy clients by ascending latency. Ejected clients are left out.
+func (p *Pool) candidates() []*poolMember {
+	var probes, healthy []*poolMember
+	for _, m := range p.members {
+		switch m.breaker.State() {
+		case BreakerHalfOpen:
+			probes = append(probes, m)
+		case BreakerClosed:
+			healthy = append(healthy, m)
+		}
+	}
+	sort.SliceStable(healthy, func(i, j int) bool { return healthy[i].averageLatency() < healthy[j].averageLatency() })
+	return append(probes, healthy...)
+}
+
+// do runs `fn` against the members of the pool until it succeeds, fails with an error that another client would not
+// fix, or the attempts are exhausted.
+func (p *Pool) do(ctx context.Context, fn func(context.Context, *AzureCSTextToSpeech) (*SynthesisResult, error)) (*SynthesisResult, error) {
+	var lastErr error
+	attempts := 0
+	for _, m := range p.candidates() {
+		if attempts == p.opts.MaxAttempts {
+			break
+		}
+		if !m.breaker.allow() {
+			// another request claimed the probe.
+			continue
+		}
+		attempts++
+
+		attemptCtx, cancel := context.WithTimeout(ctx, p.opts.AttemptTimeout)
+		result, err := fn(attemptCtx, m.client)
+		cancel()
+		switch {
+		case err == nil:
+			m.breaker.success()
+			m.record(result.Latency, true)
+			return result, nil
+		case ctx.Err() != nil:
+			m.breaker.abort()
+			return nil, ctx.Err()
+		case !poolFailover(err):
+			// the client answered, the request itself is at fault.
+			m.breaker.success()
+			return nil, err
+		}
+		m.breaker.failure()
+		m.record(0, false)
+		lastErr = err
+	}
+	if lastErr == nil {
+		// every client is ejected, or being probed by another request.
+		return nil, p.openError()
+	}
+	return nil, fmt.Errorf("all %d attempts failed, last error: %w", attempts, lastErr)
+}
+
+// openError returns the error of a request finding every client ejected or probed by another request: a
+// *CircuitOpenError lasting until the first client is due a probe.
+func (p *Pool) openError() error {
+	e := &CircuitOpenError{State: BreakerHalfOpen}
+	for _, m := range p.members {
+		until := m.breaker.reopensAt()
+		if until.IsZero() {
+			continue
+		}
+		if e.Until.IsZero() || until.Before(e.Until) {
+			e.State = BreakerOpen
+			e.Until = until
+		}
+	}
+	return e
+}
+
+// poolFailover returns true for the errors failing a request over to the next client of a pool. On top of failover,
+// authentication errors are included, as the clients of a pool may use different keys.
+func poolFailover(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) && (se.StatusCode == http.StatusUnauthorized || se.StatusCode == http.StatusForbidden) {
+		return true
+	}
+	return failover(err)
+}
+
+// failover returns true for errors another client may not run into: server errors, throttling, timeouts, network
+// failures and open circuit breakers.
+func failover(err error) bool {
+	var oe *CircuitOpenError
+	if errors.As(err, &oe) {
+		return true
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode >= 500 || se.StatusCode == 429
+	}
+	var ne net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &ne)
+}
+
+func (m *poolMember) averageLatency() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latency
+}
+
+// record accounts a request, folding the latency of a success into the moving average. Results served without an
+// upstream request carry no latency and leave the average as is.
+func (m *poolMember) record(latency time.Duration, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !ok {
+		m.failed++
+		return
+	}
+	m.served++
+	if latency <= 0 {
+		return
+	}
+	if m.latency == 0 {
+		m.latency = latency
+	} else {
+		m.latency = time.Duration(poolLatencyWeight*float64(latency) + (1-poolLatencyWeight)*float64(m.latency))
+	}
+}
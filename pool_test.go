@@ -0,0 +1,171 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+// regionServer answers with `audio`, or with the status code held by `status` when it is not zero.
+func regionServer(audio []byte, status *int32, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if code := atomic.LoadInt32(status); code != 0 {
+			w.WriteHeader(int(code))
+			return
+		}
+		w.Write(audio)
+	}))
+}
+
+func regionClient(url string) *AzureCSTextToSpeech {
+	return &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: url,
+	}
+}
+
+func TestPoolFailover(t *testing.T) {
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	var status1, status2, requests1, requests2 int32
+	ts1 := regionServer(audio, &status1, &requests1)
+	defer ts1.Close()
+	ts2 := regionServer(audio, &status2, &requests2)
+	defer ts2.Close()
+
+	p, err := NewPool([]*AzureCSTextToSpeech{regionClient(ts1.URL), regionClient(ts2.URL)}, PoolOptions{FailureThreshold: 2, EjectionTime: 50 * time.Millisecond})
+	assert.NoError(t, err)
+	synthesize := func() ([]byte, error) {
+		return p.SynthesizeWithContext(context.Background(), "hello", LocaleEnUS, GenderFemale, AudioRIFF8Bit8kHzMonoPCM)
+	}
+
+	// the first region has an incident, requests fail over and it is ejected after two failures.
+	atomic.StoreInt32(&status1, http.StatusServiceUnavailable)
+	p.members[1].latency = time.Hour
+	for i := 0; i < 4; i++ {
+		b, err := synthesize()
+		assert.NoError(t, err)
+		assert.Equal(t, audio, b)
+	}
+	assert.Equal(t, int32(2), requests1)
+	health := p.Health()
+	assert.Equal(t, BreakerOpen, health[0].State)
+	assert.Equal(t, int64(2), health[0].Failed)
+	assert.Equal(t, ts1.URL, health[0].Endpoint)
+	assert.Equal(t, int64(4), health[1].Served)
+
+	// once recovered, the probe returns the region to rotation.
+	atomic.StoreInt32(&status1, 0)
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, BreakerHalfOpen, p.Health()[0].State)
+	_, err = synthesize()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), requests1)
+	assert.Equal(t, BreakerClosed, p.Health()[0].State)
+
+	// client errors are not retried elsewhere.
+	atomic.StoreInt32(&status1, http.StatusBadRequest)
+	atomic.StoreInt32(&status2, http.StatusBadRequest)
+	before := atomic.LoadInt32(&requests1) + atomic.LoadInt32(&requests2)
+	_, err = synthesize()
+	var se *StatusError
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, http.StatusBadRequest, se.StatusCode)
+	assert.Equal(t, before+1, atomic.LoadInt32(&requests1)+atomic.LoadInt32(&requests2))
+
+	// every region failing.
+	atomic.StoreInt32(&status1, http.StatusInternalServerError)
+	atomic.StoreInt32(&status2, http.StatusTooManyRequests)
+	_, err = synthesize()
+	assert.True(t, errors.As(err, &se))
+	assert.Contains(t, err.Error(), "all 2 attempts failed")
+
+	// once every region is ejected, requests fail with an open circuit.
+	_, err = synthesize()
+	assert.Error(t, err)
+	before = atomic.LoadInt32(&requests1) + atomic.LoadInt32(&requests2)
+	_, err = synthesize()
+	var oe *CircuitOpenError
+	assert.True(t, errors.As(err, &oe))
+	assert.Equal(t, BreakerOpen, oe.State)
+	assert.False(t, oe.Until.IsZero())
+	assert.Equal(t, before, atomic.LoadInt32(&requests1)+atomic.LoadInt32(&requests2))
+}
+
+func TestPoolFailoverAuthentication(t *testing.T) {
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	var status1, status2, requests1, requests2 int32
+	ts1 := regionServer(audio, &status1, &requests1)
+	defer ts1.Close()
+	ts2 := regionServer(audio, &status2, &requests2)
+	defer ts2.Close()
+
+	p, err := NewPool([]*AzureCSTextToSpeech{regionClient(ts1.URL), regionClient(ts2.URL)}, PoolOptions{})
+	assert.NoError(t, err)
+	p.members[1].latency = time.Hour
+
+	// the key of the first client was revoked.
+	atomic.StoreInt32(&status1, http.StatusUnauthorized)
+	b, err := p.SynthesizeWithContext(context.Background(), "hello", LocaleEnUS, GenderFemale, AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err)
+	assert.Equal(t, audio, b)
+	assert.Equal(t, int32(1), requests1)
+	assert.Equal(t, int64(1), p.Health()[0].Failed)
+}
+
+func TestPoolLatencyRouting(t *testing.T) {
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	var status, fast, slow int32
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&slow, 1)
+		time.Sleep(20 * time.Millisecond)
+		w.Write(audio)
+	}))
+	defer slowServer.Close()
+	fastServer := regionServer(audio, &status, &fast)
+	defer fastServer.Close()
+
+	p, err := NewPool([]*AzureCSTextToSpeech{regionClient(slowServer.URL), regionClient(fastServer.URL)}, PoolOptions{})
+	assert.NoError(t, err)
+	// let each region serve a request to measure it.
+	p.members[1].latency = time.Hour
+	_, err = p.SynthesizeWithContext(context.Background(), "hello", LocaleEnUS, GenderFemale, AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err)
+	p.members[1].latency = 0
+	for i := 0; i < 5; i++ {
+		_, err = p.SynthesizeWithContext(context.Background(), "hello", LocaleEnUS, GenderFemale, AudioRIFF8Bit8kHzMonoPCM)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), slow)
+	assert.Equal(t, int32(5), fast)
+	assert.True(t, p.Health()[0].Latency > p.Health()[1].Latency)
+}
+
+func TestPoolTimeout(t *testing.T) {
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	release := make(chan struct{})
+	var hung, status, requests int32
+	ts1 := blockingServer(audio, release, &hung)
+	defer ts1.Close()
+	defer close(release)
+	ts2 := regionServer(audio, &status, &requests)
+	defer ts2.Close()
+
+	p, err := NewPool([]*AzureCSTextToSpeech{regionClient(ts1.URL), regionClient(ts2.URL)}, PoolOptions{AttemptTimeout: 20 * time.Millisecond})
+	assert.NoError(t, err)
+	p.members[1].latency = time.Hour
+	b, err := p.SynthesizeWithContext(context.Background(), "hello", LocaleEnUS, GenderFemale, AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err, "a hanging region fails over")
+	assert.Equal(t, audio, b)
+	assert.Equal(t, int32(1), hung)
+
+	_, err = NewPool(nil, PoolOptions{})
+	assert.Error(t, err)
+}
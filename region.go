@@ -0,0 +1,265 @@
+package azuretexttospeech
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Region references the locations of the availability of standard voices.
+// See https://docs.microsoft.com/en-us/azure/cognitive-services/speech-service/regions#standard-voices
+type Region int
+
+const (
+	// Azure regions and their endpoints that support the Text To Speech service.
+	RegionAustraliaEast Region = iota
+	RegionBrazilSouth
+	RegionCanadaCentral
+	RegionCentralUS
+	RegionEastAsia
+	RegionEastUS
+	RegionEastUS2
+	RegionFranceCentral
+	RegionIndiaCentral
+	RegionJapanEast
+	RegionJapanWest
+	RegionKoreaCentral
+	RegionNorthCentralUS
+	RegionNorthEurope
+	RegionSouthCentralUS
+	RegionSoutheastAsia
+	RegionUKSouth
+	RegionWestEurope
+	RegionWestUS
+	RegionWestUS2
+	// Regions added after the original table are appended to keep the numeric values of the above stable.
+	RegionGermanyWestCentral
+	RegionJioIndiaWest
+	RegionNorwayEast
+	RegionQatarCentral
+	RegionSouthAfricaNorth
+	RegionSwedenCentral
+	RegionSwitzerlandNorth
+	RegionSwitzerlandWest
+	RegionUAENorth
+	RegionWestCentralUS
+	RegionWestUS3
+	// Sovereign cloud regions.
+	RegionUSGovArizona
+	RegionUSGovVirginia
+	RegionChinaEast2
+	RegionChinaNorth2
+	RegionChinaNorth3
+)
+
+// Cloud identifies the Azure cloud (public or sovereign) in which a Region is hosted. Each cloud is served from its own
+// set of endpoints.
+type Cloud int
+
+const (
+	CloudPublic       Cloud = iota // Azure public cloud.
+	CloudUSGovernment              // Azure Government (US).
+	CloudChina                     // Azure China, operated by 21Vianet.
+)
+
+func (c Cloud) String() string {
+	switch c {
+	case CloudPublic:
+		return "AzurePublic"
+	case CloudUSGovernment:
+		return "AzureUSGovernment"
+	case CloudChina:
+		return "AzureChina"
+	}
+	return fmt.Sprintf("Cloud(%d)", c)
+}
+
+// cloudAPI holds the endpoint templates for a Cloud, each expecting the region name.
+type cloudAPI struct {
+	textToSpeech   string
+	tokenRefresh   string
+	voiceList      string
+	customVoice    string
+	batchSynthesis string
+}
+
+var cloudAPIs = map[Cloud]cloudAPI{
+	CloudPublic: {
+		textToSpeech:   textToSpeechAPI,
+		tokenRefresh:   tokenRefreshAPI,
+		voiceList:      voiceListAPI,
+		customVoice:    customVoiceAPI,
+		batchSynthesis: batchSynthesisAPI,
+	},
+	CloudUSGovernment: {
+		textToSpeech:   "https://%s.tts.speech.azure.us/cognitiveservices/v1",
+		tokenRefresh:   "https://%s.api.cognitive.microsoft.us/sts/v1.0/issueToken",
+		voiceList:      "https://%s.tts.speech.azure.us/cognitiveservices/voices/list",
+		customVoice:    "https://%s.voice.speech.azure.us/cognitiveservices/v1",
+		batchSynthesis: "https://%s.api.cognitive.microsoft.us/texttospeech/batchsyntheses",
+	},
+	CloudChina: {
+		textToSpeech:   "https://%s.tts.speech.azure.cn/cognitiveservices/v1",
+		tokenRefresh:   "https://%s.api.cognitive.azure.cn/sts/v1.0/issueToken",
+		voiceList:      "https://%s.tts.speech.azure.cn/cognitiveservices/voices/list",
+		customVoice:    "https://%s.voice.speech.azure.cn/cognitiveservices/v1",
+		batchSynthesis: "https://%s.api.cognitive.azure.cn/texttospeech/batchsyntheses",
+	},
+}
+
+// regionInfo is the metadata for a single Region.
+type regionInfo struct {
+	name      string
+	geography string
+	cloud     Cloud
+}
+
+// regions is indexed by Region.
+var regions = [...]regionInfo{
+	RegionAustraliaEast:      {"australiaeast", "Australia", CloudPublic},
+	RegionBrazilSouth:        {"brazilsouth", "Brazil", CloudPublic},
+	RegionCanadaCentral:      {"canadacentral", "Canada", CloudPublic},
+	RegionCentralUS:          {"centralus", "United States", CloudPublic},
+	RegionEastAsia:           {"eastasia", "Asia Pacific", CloudPublic},
+	RegionEastUS:             {"eastus", "United States", CloudPublic},
+	RegionEastUS2:            {"eastus2", "United States", CloudPublic},
+	RegionFranceCentral:      {"francecentral", "France", CloudPublic},
+	RegionIndiaCentral:       {"centralindia", "India", CloudPublic},
+	RegionJapanEast:          {"japaneast", "Japan", CloudPublic},
+	RegionJapanWest:          {"japanwest", "Japan", CloudPublic},
+	RegionKoreaCentral:       {"koreacentral", "Korea", CloudPublic},
+	RegionNorthCentralUS:     {"northcentralus", "United States", CloudPublic},
+	RegionNorthEurope:        {"northeurope", "Europe", CloudPublic},
+	RegionSouthCentralUS:     {"southcentralus", "United States", CloudPublic},
+	RegionSoutheastAsia:      {"southeastasia", "Asia Pacific", CloudPublic},
+	RegionUKSouth:            {"uksouth", "United Kingdom", CloudPublic},
+	RegionWestEurope:         {"westeurope", "Europe", CloudPublic},
+	RegionWestUS:             {"westus", "United States", CloudPublic},
+	RegionWestUS2:            {"westus2", "United States", CloudPublic},
+	RegionGermanyWestCentral: {"germanywestcentral", "Germany", CloudPublic},
+	RegionJioIndiaWest:       {"jioindiawest", "India", CloudPublic},
+	RegionNorwayEast:         {"norwayeast", "Norway", CloudPublic},
+	RegionQatarCentral:       {"qatarcentral", "Qatar", CloudPublic},
+	RegionSouthAfricaNorth:   {"southafricanorth", "South Africa", CloudPublic},
+	RegionSwedenCentral:      {"swedencentral", "Sweden", CloudPublic},
+	RegionSwitzerlandNorth:   {"switzerlandnorth", "Switzerland", CloudPublic},
+	RegionSwitzerlandWest:    {"switzerlandwest", "Switzerland", CloudPublic},
+	RegionUAENorth:           {"uaenorth", "United Arab Emirates", CloudPublic},
+	RegionWestCentralUS:      {"westcentralus", "United States", CloudPublic},
+	RegionWestUS3:            {"westus3", "United States", CloudPublic},
+	RegionUSGovArizona:       {"usgovarizona", "United States", CloudUSGovernment},
+	RegionUSGovVirginia:      {"usgovvirginia", "United States", CloudUSGovernment},
+	RegionChinaEast2:         {"chinaeast2", "China", CloudChina},
+	RegionChinaNorth2:        {"chinanorth2", "China", CloudChina},
+	RegionChinaNorth3:        {"chinanorth3", "China", CloudChina},
+}
+
+// regionAliases maps historical or alternate spellings onto a Region.
+var regionAliases = map[string]Region{
+	"indiacentral": RegionIndiaCentral, // value emitted by earlier releases of this package.
+}
+
+// IsValid returns true if the Region is listed in the region table.
+func (t Region) IsValid() bool {
+	return t >= 0 && int(t) < len(regions)
+}
+
+func (t Region) String() string {
+	if !t.IsValid() {
+		return fmt.Sprintf("Region(%d)", t)
+	}
+	return regions[t].name
+}
+
+// Geography returns the Azure geography that hosts the Region, e.g. "Europe" or "United States".
+func (t Region) Geography() string {
+	if !t.IsValid() {
+		return ""
+	}
+	return regions[t].geography
+}
+
+// Cloud returns the Azure cloud hosting the Region.
+func (t Region) Cloud() Cloud {
+	if !t.IsValid() {
+		return CloudPublic
+	}
+	return regions[t].cloud
+}
+
+// api returns the endpoint templates for the cloud hosting the Region.
+func (t Region) api() cloudAPI {
+	return cloudAPIs[t.Cloud()]
+}
+
+// Regions returns all known regions in declaration order.
+func Regions() []Region {
+	r := make([]Region, len(regions))
+	for i := range regions {
+		r[i] = Region(i)
+	}
+	return r
+}
+
+// ParseRegion returns the Region matching `s`. Matching is case-insensitive and ignores spaces, so both the
+// region name ("westeurope") and the display name ("West Europe") are accepted.
+func ParseRegion(s string) (Region, error) {
+	name := strings.ToLower(strings.Join(strings.Fields(s), ""))
+	for i, r := range regions {
+		if r.name == name {
+			return Region(i), nil
+		}
+	}
+	if r, ok := regionAliases[name]; ok {
+		return r, nil
+	}
+	return 0, fmt.Errorf("%s does not belong to Region values", s)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface for Region
+func (t Region) MarshalText() ([]byte, error) {
+	if !t.IsValid() {
+		return nil, fmt.Errorf("unable to marshal invalid %s", t)
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface for Region
+func (t *Region) UnmarshalText(text []byte) error {
+	var err error
+	*t, err = ParseRegion(string(text))
+	return err
+}
+
+// MarshalJSON implements the json.Marshaler interface for Region
+func (t Region) MarshalJSON() ([]byte, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Region. Numeric values, as written by earlier
+// releases of this package, are accepted as well.
+func (t *Region) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '"' {
+		var i int
+		if err := json.Unmarshal(data, &i); err != nil {
+			return fmt.Errorf("Region should be a string, got %s", data)
+		}
+		if !Region(i).IsValid() {
+			return fmt.Errorf("%d does not belong to Region values", i)
+		}
+		*t = Region(i)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Region should be a string, got %s", data)
+	}
+	return t.UnmarshalText([]byte(s))
+}
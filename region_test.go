@@ -0,0 +1,73 @@
+package azuretexttospeech
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionString(t *testing.T) {
+	assert.Equal(t, "westeurope", RegionWestEurope.String())
+	assert.Equal(t, "swedencentral", RegionSwedenCentral.String())
+	assert.Equal(t, "Region(-1)", Region(-1).String())
+	assert.Equal(t, "Region(1000)", Region(1000).String())
+	assert.False(t, Region(1000).IsValid())
+
+	// numeric values of the original table must remain stable.
+	assert.Equal(t, Region(19), RegionWestUS2)
+}
+
+func TestParseRegion(t *testing.T) {
+	for _, r := range Regions() {
+		p, err := ParseRegion(r.String())
+		assert.NoError(t, err)
+		assert.Equal(t, r, p)
+	}
+
+	r, err := ParseRegion("Switzerland North")
+	assert.NoError(t, err)
+	assert.Equal(t, RegionSwitzerlandNorth, r)
+
+	r, err = ParseRegion("indiacentral")
+	assert.NoError(t, err)
+	assert.Equal(t, RegionIndiaCentral, r)
+
+	_, err = ParseRegion("atlantis")
+	assert.Error(t, err)
+}
+
+func TestRegionMetadata(t *testing.T) {
+	assert.Equal(t, "Qatar", RegionQatarCentral.Geography())
+	assert.Equal(t, CloudPublic, RegionWestEurope.Cloud())
+	assert.Equal(t, CloudUSGovernment, RegionUSGovVirginia.Cloud())
+	assert.Equal(t, CloudChina, RegionChinaEast2.Cloud())
+	assert.Equal(t, "https://chinaeast2.tts.speech.azure.cn/cognitiveservices/v1", fmt.Sprintf(RegionChinaEast2.api().textToSpeech, RegionChinaEast2))
+	assert.Equal(t, "https://westeurope.tts.speech.microsoft.com/cognitiveservices/v1", fmt.Sprintf(RegionWestEurope.api().textToSpeech, RegionWestEurope))
+	assert.Equal(t, "https://westeurope.api.cognitive.microsoft.com/texttospeech/batchsyntheses", fmt.Sprintf(RegionWestEurope.api().batchSynthesis, RegionWestEurope))
+}
+
+func TestRegionJSON(t *testing.T) {
+	type config struct {
+		Region Region `json:"region"`
+	}
+
+	b, err := json.Marshal(config{RegionSwedenCentral})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"region":"swedencentral"}`, string(b))
+
+	var c config
+	assert.NoError(t, json.Unmarshal([]byte(`{"region":"westeurope"}`), &c))
+	assert.Equal(t, RegionWestEurope, c.Region)
+
+	// legacy numeric encoding.
+	assert.NoError(t, json.Unmarshal([]byte(`{"region":5}`), &c))
+	assert.Equal(t, RegionEastUS, c.Region)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"region":"atlantis"}`), &c))
+	assert.Error(t, json.Unmarshal([]byte(`{"region":999}`), &c))
+
+	_, err = json.Marshal(config{Region(999)})
+	assert.Error(t, err)
+}
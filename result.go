@@ -0,0 +1,133 @@
+package azuretexttospeech
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jesseward/azuretexttospeech/mp3"
+	"github.com/jesseward/azuretexttospeech/ogg"
+	"github.com/jesseward/azuretexttospeech/wav"
+)
+
+// SynthesisResult captures the rendered audio of a synthesis request alongside the details of how it was produced.
+type SynthesisResult struct {
+	Audio            []byte
+	AudioOutput      AudioOutput   // format of `Audio`.
+	Voice            string        // short name of the voice that rendered the audio, e.g. "en-US-JennyNeural".
+	Text             string        // speech text of the request.
+	Cached           bool          // the audio was served by AzureCSTextToSpeech.Cache, nothing was billed.
+	Coalesced        bool          // the audio was shared by an identical concurrent request, nothing was billed.
+	RequestID        string        // request identifier assigned by Azure, useful when raising a support case.
+	BilledCharacters int           // characters billed for the request, see billableSSML.
+	Latency          time.Duration // time from sending the request until the full response body was read.
+	TimeToFirstByte  time.Duration // time from sending the request until the first byte of the response arrived.
+	Duration         time.Duration // playback duration of `Audio`; zero when it cannot be derived from the format.
+}
+
+// PostProcessor transforms the result of a successful synthesis request before it is returned to the caller, see
+// AzureCSTextToSpeech.PostProcessors.
+type PostProcessor func(*SynthesisResult) (*SynthesisResult, error)
+
+// requestID returns the Azure request identifier from the response headers.
+func requestID(h http.Header) string {
+	if id := h.Get("X-RequestId"); id != "" {
+		return id
+	}
+	return h.Get("apim-request-id")
+}
+
+// billableCharacters returns the number of characters Azure bills for `speechText`. Every code point counts as a
+// single character, with the exception of Chinese characters (including kanji and hanja) which count as two.
+// See: https://azure.microsoft.com/en-us/pricing/details/cognitive-services/speech-services/
+func billableCharacters(speechText string) int {
+	n := 0
+	for _, r := range speechText {
+		if unicode.Is(unicode.Han, r) {
+			n += 2
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// billableSSML returns the number of characters Azure bills for the SSML document `payload`. Everything within its voice
+// elements is billed, markup included, while the speak and voice tags themselves are not. `speechText` is billed
+// instead when the document cannot be parsed, e.g. when plain text carries unescaped markup characters.
+func billableSSML(payload, speechText string) int {
+	d := xml.NewDecoder(strings.NewReader(payload))
+	n := 0
+	depth := 0 // of the voice elements enclosing the token.
+	for {
+		start := d.InputOffset()
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return billableCharacters(speechText)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "voice" {
+				depth++
+				continue
+			}
+			if t.Name.Local == "speak" {
+				continue
+			}
+		case xml.EndElement:
+			if t.Name.Local == "voice" {
+				depth--
+				continue
+			}
+			if t.Name.Local == "speak" {
+				continue
+			}
+		}
+		if depth > 0 {
+			n += billableCharacters(payload[start:d.InputOffset()])
+		}
+	}
+	return n
+}
+
+// audioDuration derives the playback duration of `b` from the layout of `audioOutput`. PCM, G.711, MP3 and Ogg outputs
+// are exact, other constant bitrate outputs are estimated from their bitrate. Zero is returned for formats without a
+// fixed layout.
+func audioDuration(b []byte, audioOutput AudioOutput) time.Duration {
+	f := audioOutput.Format()
+	payload := len(b)
+
+	switch f.Container {
+	case ContainerRIFF:
+		w, err := wav.Decode(b)
+		if err != nil {
+			return 0
+		}
+		payload = len(w.Data)
+	case ContainerMP3:
+		if s, err := mp3.Parse(b); err == nil {
+			return s.Duration()
+		}
+	case ContainerOgg:
+		d, _ := ogg.Duration(b)
+		return d
+	case ContainerRaw:
+	default:
+		return 0
+	}
+
+	bitrate := f.Bitrate
+	if f.BitDepth > 0 {
+		bitrate = f.SampleRate * f.BitDepth * f.Channels
+	}
+	if bitrate == 0 {
+		return 0
+	}
+	return time.Duration(int64(payload) * 8 * int64(time.Second) / int64(bitrate))
+}
@@ -0,0 +1,87 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+// riffFixture returns a RIFF/WAVE file holding `n` bytes of silent audio.
+func riffFixture(tag uint16, rate, depth, n int) []byte {
+	return wav.Encode(wav.Format{Tag: tag, Channels: 1, SampleRate: rate, BitsPerSample: depth}, make([]byte, n))
+}
+
+// mp3Fixture returns `n` silent frames of audio-16khz-32kbitrate-mono-mp3, 36ms each.
+func mp3Fixture(n int) []byte {
+	b := make([]byte, 0, n*144)
+	for i := 0; i < n; i++ {
+		f := make([]byte, 144)
+		copy(f, []byte{0xff, 0xf3, 0x48, 0xc0})
+		b = append(b, f...)
+	}
+	return b
+}
+
+func TestBillableCharacters(t *testing.T) {
+	assert.Equal(t, 0, billableCharacters(""))
+	assert.Equal(t, 5, billableCharacters("READY"))
+	assert.Equal(t, 6, billableCharacters("héllo!"))
+	assert.Equal(t, 4, billableCharacters("你好"))
+	assert.Equal(t, 5, billableCharacters("日本ご"))
+}
+
+func TestBillableSSML(t *testing.T) {
+	assert.Equal(t, len("hello"), billableSSML(voiceXML("hello", "en-US-JennyNeural", LocaleEnUS, GenderFemale), "hello"))
+	assert.Equal(t, 4, billableSSML("<speak><voice name='zh-CN-XiaoxiaoNeural'>你好</voice></speak>", ""))
+
+	// markup within the voice is billed, the speak and voice tags are not.
+	ssml := "<speak version='1.0'>\n<voice name='a'><prosody rate='slow'>Hi</prosody><break/></voice><voice name='b'>there</voice></speak>"
+	assert.Equal(t, len("<prosody rate='slow'>Hi</prosody><break/>there"), billableSSML(ssml, ""))
+
+	// documents that cannot be parsed bill the speech text.
+	assert.Equal(t, len("fish & chips"), billableSSML(voiceXML("fish & chips", "en-US-JennyNeural", LocaleEnUS, GenderFemale), "fish & chips"))
+}
+
+func TestAudioDuration(t *testing.T) {
+	assert.Equal(t, time.Second, audioDuration(riffFixture(1, 24000, 16, 48000), AudioRIFF24khz16bitMonoPcm))
+	assert.Equal(t, 500*time.Millisecond, audioDuration(make([]byte, 4000), AudioRAW8Bit8kHzMonoMulaw))
+	assert.Equal(t, 2*time.Second, audioDuration(make([]byte, 8000), Audio16khz32kbitrateMonoMp3), "estimated from the bitrate")
+	assert.Equal(t, 360*time.Millisecond, audioDuration(mp3Fixture(10), Audio16khz32kbitrateMonoMp3), "exact from the frames")
+	assert.Equal(t, time.Duration(0), audioDuration(make([]byte, 8000), AudioOgg24khz16bitMonoOpus))
+	assert.Equal(t, time.Duration(0), audioDuration([]byte("<html>"), AudioRIFF24khz16bitMonoPcm))
+}
+
+func TestSynthesizeResult(t *testing.T) {
+	audio := riffFixture(1, 16000, 16, 32000)
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-RequestId", "SYS64738")
+			w.Write(audio)
+		}),
+	)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		accessToken:     "SYS49152",
+		textToSpeechURL: ts.URL,
+		RegionVoiceMap: map[supportedVoices]string{
+			{GenderFemale, LocaleEnUS}: "en-US-JennyNeural",
+		},
+	}
+
+	result, err := az.SynthesizeResultWithContext(context.Background(), "READY.", LocaleEnUS, GenderFemale, AudioRIFF16Bit16kHzMonoPCM)
+	assert.NoError(t, err)
+	assert.Equal(t, audio, result.Audio)
+	assert.Equal(t, AudioRIFF16Bit16kHzMonoPCM, result.AudioOutput)
+	assert.Equal(t, "en-US-JennyNeural", result.Voice)
+	assert.Equal(t, "SYS64738", result.RequestID)
+	assert.Equal(t, 6, result.BilledCharacters)
+	assert.Equal(t, time.Second, result.Duration)
+	assert.True(t, result.Latency >= result.TimeToFirstByte)
+	assert.True(t, result.TimeToFirstByte > 0)
+}
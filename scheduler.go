@@ -0,0 +1,259 @@
+package azuretexttospeech
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Priority orders the upstream requests waiting on a Scheduler, lower values are served first.
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota // requests a user is waiting on.
+	PriorityNormal                      // requests without a priority, see WithPriority.
+	PriorityBulk                        // background work such as batches, see SynthesizeBatch.
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityNormal:
+		return "normal"
+	case PriorityBulk:
+		return "bulk"
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of `ctx` carrying `p`, setting the priority of the synthesis requests made with it when
+// AzureCSTextToSpeech.Scheduler is set. Requests without a priority use PriorityNormal.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// priorityFrom returns the priority carried by `ctx`.
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// QueueFullError is returned when a request arrives while the queue of its priority class is at its MaxQueue.
+type QueueFullError struct {
+	Priority Priority
+	Depth    int // number of requests of the class already waiting.
+}
+
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("scheduler queue for %s requests is full, %d waiting", e.Priority, e.Depth)
+}
+
+// SchedulerClass configures the handling of the requests of a Priority by a Scheduler.
+type SchedulerClass struct {
+	Priority Priority
+	// Share is the maximum number of requests of the class in flight at once, keeping slots free for the other
+	// classes. Zero allows the class to use the full concurrency of the scheduler.
+	Share int
+	// MaxQueue is the maximum number of requests of the class waiting for a slot, beyond which requests fail
+	// immediately with a *QueueFullError. Zero does not limit the queue.
+	MaxQueue int
+}
+
+// SchedulerOptions configures NewScheduler. The zero value runs DefaultBatchConcurrency requests at a time, started
+// at no more than DefaultBatchRate requests per second, with the classes of DefaultSchedulerClasses.
+type SchedulerOptions struct {
+	Concurrency int     // maximum number of upstream requests in flight.
+	Rate        float64 // maximum number of upstream requests started per second, a negative rate disables the limit.
+	Classes     []SchedulerClass
+}
+
+// DefaultSchedulerClasses returns the classes used when SchedulerOptions does not set any: interactive and normal
+// requests may use every slot, while bulk requests leave a quarter of the slots, and at least one, to the others
+// unless there is a single slot.
+func DefaultSchedulerClasses(concurrency int) []SchedulerClass {
+	reserved := concurrency / 4
+	if reserved < 1 {
+		reserved = 1
+	}
+	bulk := concurrency - reserved
+	if bulk < 1 {
+		bulk = 1
+	}
+	return []SchedulerClass{
+		{Priority: PriorityInteractive},
+		{Priority: PriorityNormal},
+		{Priority: PriorityBulk, Share: bulk},
+	}
+}
+
+// SchedulerStats captures the state of a priority class of a Scheduler.
+type SchedulerStats struct {
+	Running  int   // requests in flight.
+	Queued   int   // requests waiting for a slot.
+	Served   int64 // requests granted a slot so far.
+	Rejected int64 // requests refused as the queue was full.
+}
+
+// Scheduler bounds the upstream requests of AzureCSTextToSpeech, see AzureCSTextToSpeech.Scheduler. When every slot
+// is taken, or the rate does not allow another request yet, requests wait in the queue of their priority class. Freed
+// slots go to the waiting request of the highest priority whose class has not used up its share, so interactive
+// requests overtake queued bulk work rather than waiting behind it.
+type Scheduler struct {
+	concurrency int
+	limiter     *rateLimiter
+
+	mu      sync.Mutex
+	classes []*schedulerClass // by descending priority.
+	running int
+	timer   *time.Timer // pending dispatch once the rate allows another request.
+}
+
+type schedulerClass struct {
+	SchedulerClass
+	queue *list.List // of *schedulerTicket.
+	stats SchedulerStats
+}
+
+type schedulerTicket struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// NewScheduler returns a Scheduler configured by `opts`.
+func NewScheduler(opts SchedulerOptions) (*Scheduler, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	rate := opts.Rate
+	if rate == 0 {
+		rate = DefaultBatchRate
+	}
+	classes := opts.Classes
+	if len(classes) == 0 {
+		classes = DefaultSchedulerClasses(concurrency)
+	}
+
+	s := &Scheduler{concurrency: concurrency, limiter: newRateLimiter(rate)}
+	seen := map[Priority]bool{}
+	for _, c := range classes {
+		if seen[c.Priority] {
+			return nil, fmt.Errorf("scheduler class %s is configured more than once", c.Priority)
+		}
+		if c.Share < 0 || c.MaxQueue < 0 {
+			return nil, fmt.Errorf("scheduler class %s has a negative share or queue", c.Priority)
+		}
+		seen[c.Priority] = true
+		s.classes = append(s.classes, &schedulerClass{SchedulerClass: c, queue: list.New()})
+	}
+	sort.Slice(s.classes, func(i, j int) bool { return s.classes[i].Priority < s.classes[j].Priority })
+	return s, nil
+}
+
+// Stats returns the state of every priority class.
+func (s *Scheduler) Stats() map[Priority]SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make(map[Priority]SchedulerStats, len(s.classes))
+	for _, c := range s.classes {
+		st := c.stats
+		st.Queued = c.queue.Len()
+		stats[c.Priority] = st
+	}
+	return stats
+}
+
+// class returns the class of `p`, the caller must hold the lock.
+func (s *Scheduler) class(p Priority) *schedulerClass {
+	for _, c := range s.classes {
+		if c.Priority == p {
+			return c
+		}
+	}
+	return nil
+}
+
+// acquire waits for a slot for a request of priority `p`, returning the function releasing it once the request is
+// done, or the error of `ctx` when it is done first.
+func (s *Scheduler) acquire(ctx context.Context, p Priority) (func(), error) {
+	s.mu.Lock()
+	c := s.class(p)
+	if c == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("scheduler has no class for %s requests", p)
+	}
+	if c.MaxQueue > 0 && c.queue.Len() >= c.MaxQueue {
+		c.stats.Rejected++
+		s.mu.Unlock()
+		return nil, &QueueFullError{Priority: p, Depth: c.queue.Len()}
+	}
+	t := &schedulerTicket{ready: make(chan struct{})}
+	el := c.queue.PushBack(t)
+	s.dispatch()
+	s.mu.Unlock()
+
+	release := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.running--
+		c.stats.Running--
+		s.dispatch()
+	}
+	select {
+	case <-t.ready:
+		return release, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		granted := t.granted
+		if !granted {
+			c.queue.Remove(el)
+		}
+		s.mu.Unlock()
+		if granted {
+			release()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// dispatch grants free slots to the waiting requests in order of priority, the caller must hold the lock.
+func (s *Scheduler) dispatch() {
+	for s.running < s.concurrency {
+		var next *schedulerClass
+		for _, c := range s.classes {
+			if c.queue.Len() > 0 && (c.Share == 0 || c.stats.Running < c.Share) {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return
+		}
+		if wait := s.limiter.take(time.Now()); wait > 0 {
+			if s.timer == nil {
+				s.timer = time.AfterFunc(wait, func() {
+					s.mu.Lock()
+					defer s.mu.Unlock()
+					s.timer = nil
+					s.dispatch()
+				})
+			}
+			return
+		}
+
+		t := next.queue.Remove(next.queue.Front()).(*schedulerTicket)
+		t.granted = true
+		s.running++
+		next.stats.Running++
+		next.stats.Served++
+		close(t.ready)
+	}
+}
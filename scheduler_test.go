@@ -0,0 +1,147 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+// waitForQueued blocks until `n` requests of priority `p` wait on `s`.
+func waitForQueued(s *Scheduler, p Priority, n int) {
+	for s.Stats()[p].Queued != n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	s, err := NewScheduler(SchedulerOptions{Concurrency: 1, Rate: -1})
+	assert.NoError(t, err)
+
+	release, err := s.acquire(context.Background(), PriorityNormal)
+	assert.NoError(t, err)
+
+	granted := make(chan Priority)
+	queue := func(p Priority) {
+		go func() {
+			release, err := s.acquire(context.Background(), p)
+			assert.NoError(t, err)
+			granted <- p
+			release()
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		queue(PriorityBulk)
+	}
+	waitForQueued(s, PriorityBulk, 3)
+	queue(PriorityInteractive)
+	waitForQueued(s, PriorityInteractive, 1)
+
+	release()
+	assert.Equal(t, PriorityInteractive, <-granted, "interactive requests jump the queue")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, PriorityBulk, <-granted)
+	}
+	assert.Equal(t, int64(3), s.Stats()[PriorityBulk].Served)
+}
+
+func TestSchedulerShare(t *testing.T) {
+	s, err := NewScheduler(SchedulerOptions{Concurrency: 2, Rate: -1, Classes: []SchedulerClass{
+		{Priority: PriorityInteractive},
+		{Priority: PriorityBulk, Share: 1, MaxQueue: 1},
+	}})
+	assert.NoError(t, err)
+
+	releaseBulk, err := s.acquire(context.Background(), PriorityBulk)
+	assert.NoError(t, err)
+	queued := make(chan error)
+	go func() {
+		release, err := s.acquire(context.Background(), PriorityBulk)
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+	waitForQueued(s, PriorityBulk, 1)
+
+	// the share of bulk requests keeps the second slot free.
+	releaseInteractive, err := s.acquire(context.Background(), PriorityInteractive)
+	assert.NoError(t, err)
+	releaseInteractive()
+
+	_, err = s.acquire(context.Background(), PriorityBulk)
+	var full *QueueFullError
+	assert.True(t, errors.As(err, &full))
+	assert.Equal(t, PriorityBulk, full.Priority)
+	assert.Equal(t, int64(1), s.Stats()[PriorityBulk].Rejected)
+
+	_, err = s.acquire(context.Background(), PriorityNormal)
+	assert.Error(t, err, "no class for normal requests")
+
+	releaseBulk()
+	assert.NoError(t, <-queued)
+
+	_, err = NewScheduler(SchedulerOptions{Classes: []SchedulerClass{{Priority: PriorityBulk}, {Priority: PriorityBulk}}})
+	assert.Error(t, err)
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s, err := NewScheduler(SchedulerOptions{Concurrency: 1, Rate: -1})
+	assert.NoError(t, err)
+	release, err := s.acquire(context.Background(), PriorityNormal)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.acquire(ctx, PriorityNormal)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 0, s.Stats()[PriorityNormal].Queued)
+
+	release()
+	release, err = s.acquire(context.Background(), PriorityNormal)
+	assert.NoError(t, err, "the slot is free again")
+	release()
+}
+
+func TestSchedulerRate(t *testing.T) {
+	s, err := NewScheduler(SchedulerOptions{Concurrency: 10, Rate: 100})
+	assert.NoError(t, err)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		release, err := s.acquire(context.Background(), PriorityNormal)
+		assert.NoError(t, err)
+		release()
+	}
+	assert.True(t, time.Since(start) >= 50*time.Millisecond, "requests are spaced by 10ms")
+}
+
+func TestSynthesizeScheduled(t *testing.T) {
+	var peak int32
+	ts := batchServer(riffFixture(wav.FormatMuLaw, 8000, 8, 4096), &peak)
+	defer ts.Close()
+
+	s, err := NewScheduler(SchedulerOptions{Concurrency: 4, Rate: -1})
+	assert.NoError(t, err)
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleEnUS}: "en-US-JennyNeural"},
+		textToSpeechURL: ts.URL,
+		Scheduler:       s,
+	}
+	jobs := make([]BatchJob, 12)
+	for i := range jobs {
+		jobs[i] = BatchJob{Text: "line", Locale: LocaleEnUS, Gender: GenderFemale, AudioOutput: AudioRIFF8Bit8kHzMonoPCM}
+	}
+	summary, err := az.SynthesizeBatch(context.Background(), jobs, BatchOptions{Concurrency: 8, Rate: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, 12, summary.Succeeded)
+	assert.LessOrEqual(t, peak, int32(3), "bulk requests leave a slot free")
+
+	_, err = az.SynthesizeResultWithContext(WithPriority(context.Background(), PriorityInteractive), "now", LocaleEnUS, GenderFemale, AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err)
+	stats := s.Stats()
+	assert.Equal(t, int64(12), stats[PriorityBulk].Served)
+	assert.Equal(t, int64(1), stats[PriorityInteractive].Served)
+}
@@ -0,0 +1,220 @@
+package azuretexttospeech
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/mp3"
+	"github.com/jesseward/azuretexttospeech/pcm"
+	"github.com/jesseward/azuretexttospeech/wav"
+)
+
+// DefaultSilenceThreshold is the level, in dBFS, below which audio is considered silent when SilenceOptions does not
+// set a Threshold.
+const DefaultSilenceThreshold = -50.0
+
+// DefaultMP3SilenceThreshold is the level below which MP3 frames are considered silent when SilenceOptions does not set
+// an MP3Threshold. Frames are not decoded, their level is an upper bound of their spectral coefficients in dB relative
+// to a full scale coefficient, see mp3.Frame.CoefficientLevel.
+const DefaultMP3SilenceThreshold = -60.0
+
+// SilenceOptions configures the detection of the leading and trailing silence that Azure adds to synthesized audio.
+type SilenceOptions struct {
+	// Threshold is the level in dBFS below which PCM, mu-law and A-law audio is silent, DefaultSilenceThreshold when
+	// zero.
+	Threshold float64
+	// MP3Threshold is the level below which MP3 frames are silent, DefaultMP3SilenceThreshold when zero. It is not in
+	// dBFS, see DefaultMP3SilenceThreshold.
+	MP3Threshold float64
+	MinDuration  time.Duration // silent runs shorter than this are left untouched.
+	Keep         time.Duration // silence kept before and after the speech, to avoid clipping soft onsets.
+}
+
+func (o SilenceOptions) threshold() float64 {
+	if o.Threshold == 0 {
+		return DefaultSilenceThreshold
+	}
+	return o.Threshold
+}
+
+func (o SilenceOptions) mp3Threshold() float64 {
+	if o.MP3Threshold == 0 {
+		return DefaultMP3SilenceThreshold
+	}
+	return o.MP3Threshold
+}
+
+// trim returns the number of units to drop from a silent run of `n` units each lasting `unit`.
+func (o SilenceOptions) trim(n int, unit time.Duration) int {
+	if unit <= 0 || time.Duration(n)*unit < o.MinDuration {
+		return 0
+	}
+	keep := int((o.Keep + unit - 1) / unit)
+	if keep >= n {
+		return 0
+	}
+	return n - keep
+}
+
+// TrimSilence removes the leading and trailing silence of `audio`, rendered in `audioOutput`. PCM, mu-law and A-law
+// outputs are trimmed to the sample. MP3 outputs are trimmed to whole frames, with silence detected from the side
+// information of each frame rather than by decoding it; tags are kept but the Xing/Info frame is dropped. Dropped
+// frames holding bit reservoir data of the first frame kept are kept as well, silenced, so the speech decodes intact.
+func TrimSilence(audio []byte, audioOutput AudioOutput, opts SilenceOptions) ([]byte, error) {
+	if audioOutput.Format().Container == ContainerMP3 {
+		return trimMP3Silence(audio, opts)
+	}
+
+	w, err := decodeFile(audio, audioOutput)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := w.Samples()
+	if err != nil {
+		return nil, err
+	}
+
+	lead, trail := pcm.SilentEdges(samples, w.Format.Channels, opts.threshold())
+	frames := w.NumFrames()
+	unit := time.Second / time.Duration(w.Format.SampleRate)
+	if lead == frames {
+		// all silence, keep at most the requested amount.
+		lead, trail = opts.trim(frames, unit), 0
+	} else {
+		lead, trail = opts.trim(lead, unit), opts.trim(trail, unit)
+	}
+
+	align := w.Format.BlockAlign()
+	out := *w
+	out.Data = w.Data[lead*align : (frames-trail)*align]
+	return encodeFile(&out, audioOutput)
+}
+
+func trimMP3Silence(audio []byte, opts SilenceOptions) ([]byte, error) {
+	s, err := mp3.Parse(audio)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Frames) == 0 {
+		return audio, nil
+	}
+
+	unit := s.Frames[0].Header.Duration()
+	lead, trail := s.SilentEdges(opts.mp3Threshold())
+	if lead == len(s.Frames) {
+		lead, trail = opts.trim(lead, unit), 0
+	} else {
+		lead, trail = opts.trim(lead, unit), opts.trim(trail, unit)
+	}
+
+	return s.Trim(lead, trail).Bytes(), nil
+}
+
+// TrimSilence returns a copy of the SynthesisResult with its leading and trailing silence removed, see TrimSilence.
+func (r *SynthesisResult) TrimSilence(opts SilenceOptions) (*SynthesisResult, error) {
+	audio, err := TrimSilence(r.Audio, r.AudioOutput, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := *r
+	out.Audio = audio
+	out.Duration = audioDuration(audio, r.AudioOutput)
+	return &out, nil
+}
+
+// toFrames converts `d` into a number of frames at `rate`.
+func toFrames(d time.Duration, rate int) int {
+	return int(int64(d) * int64(rate) / int64(time.Second))
+}
+
+// mp3Header returns the frame layout of the MP3 `audioOutput`.
+func mp3Header(f AudioFormat) mp3.Header {
+	h := mp3.Header{Layer: 3, Bitrate: f.Bitrate, SampleRate: f.SampleRate, ChannelMode: mp3.Mono}
+	switch {
+	case f.SampleRate >= 32000:
+		h.Version = mp3.MPEG1
+	case f.SampleRate >= 16000:
+		h.Version = mp3.MPEG2
+	default:
+		h.Version = mp3.MPEG25
+	}
+	if f.Channels > 1 {
+		h.ChannelMode = mp3.Stereo
+	}
+	return h
+}
+
+// mp3Silence returns silent frames of layout `h` lasting `d`, rounded to the nearest whole frame. The frames carry no
+// main data, so a frame placed after them that references the bit reservoir reads their padding instead of its own
+// data, see JoinWithSilence.
+func mp3Silence(h mp3.Header, d time.Duration) []byte {
+	unit := h.Duration()
+	n := int((d + unit/2) / unit)
+	return bytes.Repeat(mp3.SilentFrame(h), n)
+}
+
+// Silence returns `d` of silence rendered in `audioOutput`. PCM, mu-law and A-law outputs are exact to the sample, MP3
+// outputs are rounded to the nearest whole frame, e.g. 36ms for Audio16khz32kbitrateMonoMp3.
+func Silence(d time.Duration, audioOutput AudioOutput) ([]byte, error) {
+	f := audioOutput.Format()
+	if f.Container == ContainerMP3 {
+		return mp3Silence(mp3Header(f), d), nil
+	}
+
+	wf, ok := wavFormat(f)
+	if !ok {
+		return nil, fmt.Errorf("unable to render silence as %s, unsupported codec %s", audioOutput, f.Codec)
+	}
+	frames := toFrames(d, f.SampleRate)
+	return encodeSamples(pcm.Silence(frames, wf.Channels), wf.Channels, audioOutput)
+}
+
+// JoinWithSilence concatenates the `segments`, each rendered in `audioOutput`, separated by `gap` of silence. RIFF
+// outputs keep the header and metadata of the first segment. MP3 segments are joined as by mp3.Concat, with the gap
+// rounded to whole frames. Frames are not decoded, as such an MP3 segment whose first frame references bit reservoir
+// data, e.g. one cut from the middle of a stream by mp3.Cut, decodes with a brief glitch after the gap. Segments
+// starting at the beginning of an encoded stream, such as synthesized audio, do not reference the reservoir.
+func JoinWithSilence(gap time.Duration, audioOutput AudioOutput, segments ...[]byte) ([]byte, error) {
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("nothing to join")
+	}
+
+	if audioOutput.Format().Container == ContainerMP3 {
+		parts := make([][]byte, 0, 2*len(segments)-1)
+		for i, b := range segments {
+			s, err := mp3.Parse(b)
+			if err != nil {
+				return nil, fmt.Errorf("segment %d, %v", i, err)
+			}
+			if i > 0 && gap > 0 && len(s.Frames) > 0 {
+				// match the layout of the audio frames rather than the nominal format.
+				parts = append(parts, mp3Silence(s.Frames[0].Header, gap))
+			}
+			parts = append(parts, b)
+		}
+		return mp3.Concat(parts...)
+	}
+
+	files := make([]*wav.File, 0, 2*len(segments)-1)
+	for i, b := range segments {
+		w, err := decodeFile(b, audioOutput)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d, %v", i, err)
+		}
+		if i > 0 && gap > 0 {
+			silence := wav.New(w.Format, nil)
+			frames := toFrames(gap, w.Format.SampleRate)
+			if err := silence.SetSamples(pcm.Silence(frames, w.Format.Channels)); err != nil {
+				return nil, err
+			}
+			files = append(files, silence)
+		}
+		files = append(files, w)
+	}
+	joined, err := wav.Join(files...)
+	if err != nil {
+		return nil, err
+	}
+	return encodeFile(joined, audioOutput)
+}
@@ -0,0 +1,86 @@
+package azuretexttospeech
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimSilence(t *testing.T) {
+	opts := SilenceOptions{MinDuration: 100 * time.Millisecond, Keep: 50 * time.Millisecond}
+	for _, a := range []AudioOutput{AudioRIFF24khz16bitMonoPcm, AudioRAW8Bit8kHzMonoMulaw, AudioRIFF8khz8bitMonoALaw} {
+		lead, err := Silence(200*time.Millisecond, a)
+		assert.NoError(t, err)
+		trail, err := Silence(300*time.Millisecond, a)
+		assert.NoError(t, err)
+		padded, err := JoinWithSilence(0, a, lead, toneFixture(t, a), trail)
+		assert.NoError(t, err)
+		assert.Equal(t, 1500*time.Millisecond, audioDuration(padded, a), a.String())
+
+		out, err := TrimSilence(padded, a, opts)
+		assert.NoError(t, err)
+		assert.NoError(t, validateAudio(out, "", a), a.String())
+		assert.InDelta(t, float64(1100*time.Millisecond), float64(audioDuration(out, a)), float64(time.Millisecond), a.String())
+
+		// runs shorter than MinDuration are kept.
+		out, err = TrimSilence(padded, a, SilenceOptions{MinDuration: time.Second})
+		assert.NoError(t, err)
+		assert.Equal(t, padded, out)
+	}
+
+	_, err := TrimSilence([]byte("OggS"), AudioOgg16khz16bitMonoOpus, opts)
+	assert.Error(t, err)
+
+	// a fmt chunk with a zero sample rate is an error rather than a division by zero.
+	_, err = TrimSilence(riffFixture(wav.FormatPCM, 0, 16, 3200), AudioRIFF24khz16bitMonoPcm, opts)
+	assert.Error(t, err)
+}
+
+func TestTrimSilenceMP3(t *testing.T) {
+	loud := mp3Fixture(5)
+	for i := 0; i < 5; i++ {
+		copy(loud[i*144+5:], []byte{0x7f, 0xff, 0xff, 0xff, 0xff}) // maximal side information fields.
+	}
+	b := append(append(mp3Fixture(10), loud...), mp3Fixture(3)...)
+
+	r := &SynthesisResult{Audio: b, AudioOutput: Audio16khz32kbitrateMonoMp3}
+	out, err := r.TrimSilence(SilenceOptions{Keep: 40 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.Equal(t, (2+5+2)*36*time.Millisecond, out.Duration)
+	assert.Equal(t, loud, out.Audio[2*144:7*144])
+
+	// the first loud frame begins its main data 200 bytes back, within the two frames before it.
+	loud[4] = 200
+	b = append(append(mp3Fixture(10), loud...), mp3Fixture(3)...)
+	trimmed, err := TrimSilence(b, Audio16khz32kbitrateMonoMp3, SilenceOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, (2+5)*36*time.Millisecond, audioDuration(trimmed, Audio16khz32kbitrateMonoMp3))
+	assert.Equal(t, loud, trimmed[2*144:])
+}
+
+func TestJoinWithSilence(t *testing.T) {
+	a := AudioRIFF16Bit16kHzMonoPCM
+	tone := toneFixture(t, a)
+	out, err := JoinWithSilence(250*time.Millisecond, a, tone, tone, tone)
+	assert.NoError(t, err)
+	assert.Equal(t, 3500*time.Millisecond, audioDuration(out, a))
+
+	samples, _, err := decodeSamples(out, a)
+	assert.NoError(t, err)
+	for _, s := range samples[16000 : 16000+4000] {
+		assert.Equal(t, 0.0, s)
+	}
+
+	joined, err := JoinWithSilence(360*time.Millisecond, Audio16khz32kbitrateMonoMp3, mp3Fixture(5), mp3Fixture(5))
+	assert.NoError(t, err)
+	assert.Equal(t, 20*36*time.Millisecond, audioDuration(joined, Audio16khz32kbitrateMonoMp3))
+
+	silence, err := Silence(100*time.Millisecond, Audio24khz96kbitrateMonoMp3)
+	assert.NoError(t, err)
+	assert.NoError(t, validateAudio(silence, "audio/mpeg", Audio24khz96kbitrateMonoMp3))
+
+	_, err = JoinWithSilence(time.Second, a)
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// synthesizeSSML renders the SSML document `ssml` of a BatchJob, which is sent as is, allowing the use of prosody,
+// breaks, styles and multiple voices. `voiceName` selects the endpoint as by SynthesizeVoiceWithContext and is required
+// for custom voices. When empty, the name of the first voice element of the document is used. The Text of the result is
+// the spoken text of the document, without its markup.
+func (az *AzureCSTextToSpeech) synthesizeSSML(ctx context.Context, ssml string, voiceName string, audioOutput AudioOutput) (*SynthesisResult, error) {
+	speechText, documentVoice, err := parseSSML(ssml)
+	if err != nil {
+		return nil, err
+	}
+
+	name := voiceName
+	if name == "" {
+		name = documentVoice
+	}
+	v, err := az.resolveVoice(name)
+	if err != nil {
+		if voiceName != "" {
+			return nil, err
+		}
+		// the voice of the document is resolved by the service, it need not be known to RegionVoiceMap.
+		v = voice{name: documentVoice, endpoint: az.textToSpeechURL}
+	}
+	return az.synthesize(ctx, v, speechText, ssml, audioOutput)
+}
+
+// parseSSML returns the spoken text of the SSML document `ssml`, with its whitespace collapsed, and the name of its
+// first voice element.
+func parseSSML(ssml string) (string, string, error) {
+	d := xml.NewDecoder(strings.NewReader(ssml))
+	var text []string
+	var voiceName string
+	root := true
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("invalid SSML document, %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if root && t.Name.Local != "speak" {
+				return "", "", fmt.Errorf("invalid SSML document, root element is %s rather than speak", t.Name.Local)
+			}
+			root = false
+			if t.Name.Local == "voice" && voiceName == "" {
+				for _, a := range t.Attr {
+					if a.Name.Local == "name" {
+						voiceName = a.Value
+					}
+				}
+			}
+		case xml.CharData:
+			text = append(text, strings.Fields(string(t))...)
+		}
+	}
+	if root {
+		return "", "", fmt.Errorf("invalid SSML document, missing speak element")
+	}
+	return strings.Join(text, " "), voiceName, nil
+}
@@ -0,0 +1,66 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSSML(t *testing.T) {
+	text, voice, err := parseSSML(`<speak version='1.0' xml:lang='en-US'>
+		<voice name='en-US-JennyNeural'>Hello <break time='500ms'/> <prosody rate='slow'>world</prosody></voice>
+		<voice name='en-US-GuyNeural'>again</voice>
+	</speak>`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello world again", text)
+	assert.Equal(t, "en-US-JennyNeural", voice)
+
+	_, _, err = parseSSML("<voice name='x'>hi</voice>")
+	assert.Error(t, err, "root must be speak")
+	_, _, err = parseSSML("<speak>unterminated")
+	assert.Error(t, err)
+	_, _, err = parseSSML("plain text")
+	assert.Error(t, err)
+}
+
+func TestSynthesizeSSML(t *testing.T) {
+	var gotPath, gotBody string
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			b, _ := ioutil.ReadAll(r.Body)
+			gotBody = string(b)
+			w.Write(audio)
+		}),
+	)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{textToSpeechURL: ts.URL + "/stock", customVoiceURL: ts.URL + "/custom"}
+	assert.NoError(t, az.RegisterCustomVoice(CustomVoice{Name: "ContosoNeural", Locale: LocaleEnUS, DeploymentID: "SYS64738"}))
+
+	ssml := "<speak version='1.0' xml:lang='en-US'><voice name='en-US-JennyNeural'>Good <emphasis>morning</emphasis></voice></speak>"
+	r, err := az.synthesizeSSML(context.Background(), ssml, "", AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err)
+	assert.Equal(t, audio, r.Audio)
+	assert.Equal(t, ssml, gotBody, "the document is sent as is")
+	assert.Equal(t, "/stock", gotPath)
+	assert.Equal(t, "en-US-JennyNeural", r.Voice)
+	assert.Equal(t, "Good morning", r.Text)
+	assert.Equal(t, len("Good <emphasis>morning</emphasis>"), r.BilledCharacters, "markup within the voice is billed")
+
+	// custom voices are served from their deployment.
+	_, err = az.synthesizeSSML(context.Background(), "<speak><voice name='ContosoNeural'>hi</voice></speak>", "ContosoNeural", AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err)
+	assert.Equal(t, "/custom", gotPath)
+
+	_, err = az.synthesizeSSML(context.Background(), ssml, "UnknownNeural", AudioRIFF8Bit8kHzMonoPCM)
+	assert.Error(t, err, "an explicit voice must resolve")
+	_, err = az.synthesizeSSML(context.Background(), "hi", "", AudioRIFF8Bit8kHzMonoPCM)
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package azuretexttospeech
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/jesseward/azuretexttospeech/id3"
+	"github.com/jesseward/azuretexttospeech/mp3"
+	"github.com/jesseward/azuretexttospeech/wav"
+)
+
+// Metadata describes synthesized audio to media libraries. Empty fields are not written.
+type Metadata struct {
+	Title    string
+	Artist   string // defaults to the voice name when tagging a SynthesisResult.
+	Album    string
+	Language string        // ISO 639-2 code, e.g. "eng". Defaults to the language of the voice when tagging a SynthesisResult.
+	Comment  string        // defaults to the SHA-256 hash of the speech text when tagging a SynthesisResult.
+	Chapters []id3.Chapter // written as CHAP and CTOC frames, MP3 outputs only.
+}
+
+// languageCodes maps the ISO 639-1 language of the supported locales to the ISO 639-2 codes used by ID3 and RIFF.
+var languageCodes = map[string]string{
+	"ar": "ara", "bg": "bul", "ca": "cat", "cs": "cze", "da": "dan", "de": "ger", "el": "gre", "en": "eng",
+	"es": "spa", "et": "est", "fi": "fin", "fr": "fre", "ga": "gle", "he": "heb", "hi": "hin", "hr": "hrv",
+	"hu": "hun", "id": "ind", "it": "ita", "ja": "jpn", "ko": "kor", "lt": "lit", "lv": "lav", "mr": "mar",
+	"ms": "may", "mt": "mlt", "nb": "nob", "nl": "dut", "pl": "pol", "pt": "por", "ro": "rum", "ru": "rus",
+	"sk": "slo", "sl": "slv", "sv": "swe", "ta": "tam", "te": "tel", "th": "tha", "tr": "tur", "vi": "vie",
+	"zh": "chi",
+}
+
+// voiceLanguage returns the ISO 639-2 language of a voice named after its locale, e.g. "eng" for "en-US-JennyNeural".
+func voiceLanguage(name string) string {
+	return languageCodes[strings.ToLower(strings.SplitN(name, "-", 2)[0])]
+}
+
+// TextHash returns the comment written by default when tagging a SynthesisResult, identifying the speech text.
+func TextHash(speechText string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(speechText)))
+}
+
+// TagAudio writes `m` to `audio`, rendered in `audioOutput`. MP3 outputs receive an ID3v2.3 tag, replacing any ID3v2
+// tag already present. RIFF outputs receive a LIST/INFO chunk with the INAM, IART, IPRD, ILNG and ICMT entries; chapters
+// are not written to RIFF outputs. Other containers are not supported.
+func TagAudio(audio []byte, audioOutput AudioOutput, m Metadata) ([]byte, error) {
+	switch audioOutput.Format().Container {
+	case ContainerMP3:
+		tag, err := (&id3.Tag{
+			Title:    m.Title,
+			Artist:   m.Artist,
+			Album:    m.Album,
+			Language: m.Language,
+			Comment:  m.Comment,
+			Chapters: m.Chapters,
+		}).Bytes()
+		if err != nil {
+			return nil, err
+		}
+		n := mp3.ID3v2Size(audio)
+		if n > len(audio) {
+			return nil, fmt.Errorf("unable to tag %s, ID3v2 tag of %d bytes exceeds the audio", audioOutput, n)
+		}
+		return append(tag, audio[n:]...), nil
+	case ContainerRIFF:
+		w, err := wav.Decode(audio)
+		if err != nil {
+			return nil, err
+		}
+		info := map[string]string{}
+		for k, v := range w.Info {
+			info[k] = v
+		}
+		for k, v := range map[string]string{"INAM": m.Title, "IART": m.Artist, "IPRD": m.Album, "ILNG": m.Language, "ICMT": m.Comment} {
+			if v != "" {
+				info[k] = v
+			}
+		}
+		w.Info = info
+		return w.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unable to tag %s, unsupported container %s", audioOutput, audioOutput.Format().Container)
+}
+
+// Tag returns a copy of the SynthesisResult with `m` written to its audio, see TagAudio. The artist, language and
+// comment default to the voice name, the language of the voice and the hash of the speech text.
+func (r *SynthesisResult) Tag(m Metadata) (*SynthesisResult, error) {
+	if m.Artist == "" {
+		m.Artist = r.Voice
+	}
+	if m.Language == "" {
+		m.Language = voiceLanguage(r.Voice)
+	}
+	if m.Comment == "" && r.Text != "" {
+		m.Comment = TextHash(r.Text)
+	}
+	audio, err := TagAudio(r.Audio, r.AudioOutput, m)
+	if err != nil {
+		return nil, err
+	}
+	out := *r
+	out.Audio = audio
+	return &out, nil
+}
+
+// Tagger returns a PostProcessor writing `m` to every synthesized result, see SynthesisResult.Tag.
+func Tagger(m Metadata) PostProcessor {
+	return func(r *SynthesisResult) (*SynthesisResult, error) {
+		return r.Tag(m)
+	}
+}
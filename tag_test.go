@@ -0,0 +1,81 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/id3"
+	"github.com/jesseward/azuretexttospeech/mp3"
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagAudio(t *testing.T) {
+	a := Audio16khz32kbitrateMonoMp3
+	m := Metadata{Title: "Main menu", Album: "IVR", Chapters: []id3.Chapter{{Title: "Greeting", End: 180 * time.Millisecond}}}
+	audio := mp3Fixture(10)
+
+	out, err := TagAudio(audio, a, m)
+	assert.NoError(t, err)
+	assert.NoError(t, validateAudio(out, "audio/mpeg", a))
+	s, err := mp3.Parse(out)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, len(s.Frames))
+
+	frames, err := id3.ReadFrames(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "Main menu", frames[0].Text())
+	assert.Equal(t, "CHAP", frames[len(frames)-1].ID)
+
+	// tagging again replaces the previous tag.
+	again, err := TagAudio(out, a, Metadata{Title: "Renamed"})
+	assert.NoError(t, err)
+	frames, _ = id3.ReadFrames(again)
+	assert.Equal(t, 1, len(frames))
+	assert.Equal(t, len(audio), len(again)-id3.HeaderSize-10-len(frames[0].Data))
+
+	riff := riffFixture(wav.FormatPCM, 16000, 16, 3200)
+	out, err = TagAudio(riff, AudioRIFF16Bit16kHzMonoPCM, m)
+	assert.NoError(t, err)
+	w, err := wav.Decode(out)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"INAM": "Main menu", "IPRD": "IVR"}, w.Info)
+	assert.Equal(t, 3200, len(w.Data))
+
+	_, err = TagAudio([]byte("OggS"), AudioOgg16khz16bitMonoOpus, m)
+	assert.Error(t, err)
+
+	// a tag declaring more bytes than the audio holds, as left by a truncated download.
+	truncated := append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0x7f, 0x7f}, audio[:100]...)
+	_, err = TagAudio(truncated, a, m)
+	assert.Error(t, err)
+}
+
+func TestTagger(t *testing.T) {
+	a := AudioRIFF24khz16bitMonoPcm
+	tone := toneFixture(t, a)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tone)
+	}))
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		RegionVoiceMap:  map[supportedVoices]string{{GenderFemale, LocaleDeDE}: "de-DE-KatjaNeural"},
+		textToSpeechURL: ts.URL,
+		PostProcessors:  []PostProcessor{Tagger(Metadata{Title: "Begrüßung"})},
+	}
+	result, err := az.SynthesizeResultWithContext(context.Background(), "Guten Tag", LocaleDeDE, GenderFemale, a)
+	assert.NoError(t, err)
+
+	w, err := wav.Decode(result.Audio)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"INAM": "Begrüßung",
+		"IART": "de-DE-KatjaNeural",
+		"ILNG": "ger",
+		"ICMT": TextHash("Guten Tag"),
+	}, w.Info)
+}
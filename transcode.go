@@ -0,0 +1,99 @@
+package azuretexttospeech
+
+import (
+	"fmt"
+
+	"github.com/jesseward/azuretexttospeech/pcm"
+	"github.com/jesseward/azuretexttospeech/wav"
+)
+
+// decodeFile returns the PCM, mu-law or A-law `audio` as a wav.File, wrapping `raw-*` outputs with their layout.
+func decodeFile(audio []byte, audioOutput AudioOutput) (*wav.File, error) {
+	f := audioOutput.Format()
+	wf, ok := wavFormat(f)
+	if !ok {
+		return nil, fmt.Errorf("unable to decode samples of %s, unsupported codec %s", audioOutput, f.Codec)
+	}
+	switch f.Container {
+	case ContainerRIFF:
+		return wav.Decode(audio)
+	case ContainerRaw:
+		return wav.New(wf, audio), nil
+	}
+	return nil, fmt.Errorf("unable to decode samples of %s, unsupported container %s", audioOutput, f.Container)
+}
+
+// encodeFile renders `w` in the container of `audioOutput`.
+func encodeFile(w *wav.File, audioOutput AudioOutput) ([]byte, error) {
+	f := audioOutput.Format()
+	switch f.Container {
+	case ContainerRIFF:
+		return w.Bytes(), nil
+	case ContainerRaw:
+		return w.Data, nil
+	}
+	return nil, fmt.Errorf("unable to encode samples as %s, unsupported container %s", audioOutput, f.Container)
+}
+
+// decodeSamples returns the interleaved samples of PCM, mu-law or A-law `audio` scaled to the range [-1, 1), along with
+// the layout of the samples.
+func decodeSamples(audio []byte, audioOutput AudioOutput) ([]float64, wav.Format, error) {
+	w, err := decodeFile(audio, audioOutput)
+	if err != nil {
+		return nil, wav.Format{}, err
+	}
+	samples, err := w.Samples()
+	return samples, w.Format, err
+}
+
+// encodeSamples renders the interleaved `samples` in the PCM, mu-law or A-law `audioOutput`.
+func encodeSamples(samples []float64, channels int, audioOutput AudioOutput) ([]byte, error) {
+	f := audioOutput.Format()
+	wf, ok := wavFormat(f)
+	if !ok {
+		return nil, fmt.Errorf("unable to encode samples as %s, unsupported codec %s", audioOutput, f.Codec)
+	}
+	wf.Channels = channels
+
+	w := wav.New(wf, nil)
+	if err := w.SetSamples(samples); err != nil {
+		return nil, err
+	}
+	return encodeFile(w, audioOutput)
+}
+
+// Transcode converts PCM, mu-law or A-law `audio` in format `from` to any other PCM, mu-law or A-law format, resampling
+// and companding locally. This allows a single synthesis request, ideally at the highest sample rate required, to serve
+// several output formats.
+func Transcode(audio []byte, from, to AudioOutput) ([]byte, error) {
+	if from == to {
+		return audio, nil
+	}
+	src, dst := from.Format(), to.Format()
+	if src.Codec == dst.Codec && src.SampleRate == dst.SampleRate && src.BitDepth == dst.BitDepth {
+		// only the container differs, leave the samples untouched.
+		return ConvertContainer(audio, from, to)
+	}
+
+	samples, wf, err := decodeSamples(audio, from)
+	if err != nil {
+		return nil, err
+	}
+	if wf.SampleRate != dst.SampleRate {
+		samples = pcm.ResampleInterleaved(samples, wf.Channels, wf.SampleRate, dst.SampleRate)
+	}
+	return encodeSamples(samples, wf.Channels, to)
+}
+
+// Transcode returns a copy of the SynthesisResult with its audio converted to `to`, see Transcode.
+func (r *SynthesisResult) Transcode(to AudioOutput) (*SynthesisResult, error) {
+	audio, err := Transcode(r.Audio, r.AudioOutput, to)
+	if err != nil {
+		return nil, err
+	}
+	out := *r
+	out.Audio = audio
+	out.AudioOutput = to
+	out.Duration = audioDuration(audio, to)
+	return &out, nil
+}
@@ -0,0 +1,79 @@
+package azuretexttospeech
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+// toneFixture returns one second of a 440Hz tone in `audioOutput`.
+func toneFixture(t *testing.T, audioOutput AudioOutput) []byte {
+	rate := audioOutput.Format().SampleRate
+	s := make([]float64, rate)
+	for i := range s {
+		s[i] = 0.5 * math.Sin(2*math.Pi*440*float64(i)/float64(rate))
+	}
+	b, err := encodeSamples(s, 1, audioOutput)
+	assert.NoError(t, err)
+	return b
+}
+
+func TestTranscode(t *testing.T) {
+	src := toneFixture(t, AudioRIFF24khz16bitMonoPcm)
+
+	targets := []AudioOutput{
+		AudioRIFF16Bit16kHzMonoPCM,
+		AudioRAW16Bit16kHzMonoMulaw,
+		AudioRIFF8Bit8kHzMonoPCM,
+		AudioRAW8Bit8kHzMonoMulaw,
+		AudioRIFF8khz8bitMonoALaw,
+		AudioRAW8khz8bitMonoALaw,
+		AudioRIFF48khz16bitMonoPcm,
+		AudioRAW24khz16bitMonoPcm,
+	}
+	for _, to := range targets {
+		out, err := Transcode(src, AudioRIFF24khz16bitMonoPcm, to)
+		assert.NoError(t, err, to.String())
+		assert.NoError(t, validateAudio(out, "", to), to.String())
+		assert.Equal(t, time.Second, audioDuration(out, to), to.String())
+
+		// compare the transcoded tone against one generated natively in the target format.
+		got, _, err := decodeSamples(out, to)
+		assert.NoError(t, err)
+		want, _, _ := decodeSamples(toneFixture(t, to), to)
+		var sum float64
+		for i := 200; i < len(want)-200; i++ {
+			sum += (got[i] - want[i]) * (got[i] - want[i])
+		}
+		assert.True(t, math.Sqrt(sum/float64(len(want)-400)) < 0.02, to.String())
+	}
+}
+
+func TestTranscodeUnsupported(t *testing.T) {
+	_, err := Transcode([]byte{0xff, 0xf3}, Audio16khz32kbitrateMonoMp3, AudioRIFF16Bit16kHzMonoPCM)
+	assert.Error(t, err)
+	_, err = Transcode(toneFixture(t, AudioRIFF16Bit16kHzMonoPCM), AudioRIFF16Bit16kHzMonoPCM, AudioOgg16khz16bitMonoOpus)
+	assert.Error(t, err)
+}
+
+func TestSynthesisResultTranscode(t *testing.T) {
+	r := &SynthesisResult{
+		Audio:       toneFixture(t, AudioRIFF24khz16bitMonoPcm),
+		AudioOutput: AudioRIFF24khz16bitMonoPcm,
+		Voice:       "en-US-JennyNeural",
+		Duration:    time.Second,
+	}
+	out, err := r.Transcode(AudioRAW8Bit8kHzMonoMulaw)
+	assert.NoError(t, err)
+	assert.Equal(t, AudioRAW8Bit8kHzMonoMulaw, out.AudioOutput)
+	assert.Equal(t, 8000, len(out.Audio))
+	assert.Equal(t, "en-US-JennyNeural", out.Voice)
+	assert.Equal(t, AudioRIFF24khz16bitMonoPcm, r.AudioOutput, "the source result is left untouched")
+
+	w, err := wav.Decode(r.Audio)
+	assert.NoError(t, err)
+	assert.Equal(t, 24000, w.Format.SampleRate)
+}
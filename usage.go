@@ -0,0 +1,358 @@
+package azuretexttospeech
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// usageDayLayout and usageMonthLayout format the days and months of the usage, which follow UTC as the billing of
+// Azure does.
+const (
+	usageDayLayout   = "2006-01-02"
+	usageMonthLayout = "2006-01"
+)
+
+// VoiceTypeCustomNeural is the UsageRecord.VoiceType of Custom Neural Voices, which are billed at their own rate. Stock
+// voices are either "Neural" or "Standard".
+const VoiceTypeCustomNeural = "CustomNeural"
+
+// UsageRecord totals the characters billed for the requests of a voice on a day.
+type UsageRecord struct {
+	Day        string `json:"day"` // UTC day, e.g. "2024-05-31".
+	Voice      string `json:"voice"`
+	VoiceType  string `json:"voice_type"` // "Standard", "Neural" or VoiceTypeCustomNeural.
+	Characters int64  `json:"characters"`
+	Requests   int64  `json:"requests"`
+}
+
+// UsageStore persists the totals of a UsageMeter. Implementations must be safe for concurrent use.
+type UsageStore interface {
+	// Add adds the characters and requests of `r` to the record of the same day, voice and voice type.
+	Add(r UsageRecord) error
+	// Records returns every record, ordered by day and voice.
+	Records() ([]UsageRecord, error)
+}
+
+// usageRetryInterval is the time a UsageMeter waits after failing to read the usage of the month before reading the
+// store again.
+const usageRetryInterval = time.Minute
+
+// QuotaExceededError is returned without contacting Azure when a request would take the characters billed during the
+// month beyond UsageOptions.MonthlyBudget.
+type QuotaExceededError struct {
+	Month     string // UTC month, e.g. "2024-05".
+	Used      int64  // characters billed during the month, including requests in flight.
+	Requested int64
+	Budget    int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("monthly character budget exceeded for %s, %d used and %d requested of %d", e.Month, e.Used, e.Requested, e.Budget)
+}
+
+// UsageOptions configures NewUsageMeter.
+type UsageOptions struct {
+	Store UsageStore // holds the totals, a MemoryUsageStore when nil.
+	// MonthlyBudget is the maximum number of characters billed per calendar month, e.g. 500000 for the free tier.
+	// Zero does not limit the requests.
+	MonthlyBudget int64
+}
+
+// UsageMeter accounts the characters billed for the requests of AzureCSTextToSpeech, see AzureCSTextToSpeech.Usage.
+// Requests served from the cache or shared with an identical request are not billed, and neither are failed requests.
+// The budget is checked against a running total of the month, loaded from the store when the month starts, so usage
+// added to a shared store by other processes afterwards is not counted against it.
+//
+// A failing store does not stop synthesis: while the usage of the month cannot be read, requests are let through
+// without checking the budget, and the store is read again at most once a minute. An outage of the store can therefore
+// let the usage exceed the budget, which is checked again once the store recovers.
+type UsageMeter struct {
+	store  UsageStore
+	budget int64
+	now    func() time.Time
+
+	loadMu  sync.Mutex // serializes the reads of the usage of the month, without holding up the budget checks.
+	retryAt time.Time  // earliest time to read the usage of the month again after a failure.
+
+	mu      sync.Mutex
+	month   string // month of `used`, empty until loaded.
+	used    int64  // characters billed during `month`.
+	pending int64  // characters of the requests in flight, counted against the budget.
+}
+
+// NewUsageMeter returns a UsageMeter configured by `opts`.
+func NewUsageMeter(opts UsageOptions) *UsageMeter {
+	m := &UsageMeter{store: opts.Store, budget: opts.MonthlyBudget, now: time.Now}
+	if m.store == nil {
+		m.store = NewMemoryUsageStore()
+	}
+	return m
+}
+
+// Records returns the totals per day and voice, ordered by day and voice.
+func (m *UsageMeter) Records() ([]UsageRecord, error) {
+	return m.store.Records()
+}
+
+// Month returns the characters billed during the UTC calendar month of `t`.
+func (m *UsageMeter) Month(t time.Time) (int64, error) {
+	records, err := m.store.Records()
+	if err != nil {
+		return 0, fmt.Errorf("unable to read usage, %v", err)
+	}
+	month := t.UTC().Format(usageMonthLayout)
+	var n int64
+	for _, r := range records {
+		if strings.HasPrefix(r.Day, month) {
+			n += r.Characters
+		}
+	}
+	return n, nil
+}
+
+// reserve claims `characters` of the budget for a request, returning a *QuotaExceededError when the request would
+// exceed it. A reservation must be followed by a call to commit or cancel.
+func (m *UsageMeter) reserve(characters int) error {
+	checked := m.budget > 0
+	if checked {
+		now := m.now()
+		month := now.UTC().Format(usageMonthLayout)
+		// the usage of the month is read without holding the lock, so that a slow store does not hold up the requests.
+		checked = m.loaded(month) || m.load(now, month)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if checked {
+		used := m.used + m.pending
+		if used+int64(characters) > m.budget {
+			return &QuotaExceededError{
+				Month:     m.month,
+				Used:      used,
+				Requested: int64(characters),
+				Budget:    m.budget,
+			}
+		}
+	}
+	m.pending += int64(characters)
+	return nil
+}
+
+// loaded returns true when the running total holds the usage of `month`.
+func (m *UsageMeter) loaded(month string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.month == month
+}
+
+// load reads the usage of `month`, the UTC month of `now`, into the running total. It returns false when the store
+// fails, or has failed within the last usageRetryInterval, and the budget cannot be checked.
+func (m *UsageMeter) load(now time.Time, month string) bool {
+	m.loadMu.Lock()
+	defer m.loadMu.Unlock()
+	if m.loaded(month) {
+		// loaded by a concurrent request.
+		return true
+	}
+	if now.Before(m.retryAt) {
+		return false
+	}
+	used, err := m.Month(now)
+	if err != nil {
+		log.Printf("unable to check the monthly character budget, %v", err)
+		m.retryAt = now.Add(usageRetryInterval)
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.month, m.used = month, used
+	return true
+}
+
+// cancel releases the reservation of a request that was not billed.
+func (m *UsageMeter) cancel(characters int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending -= int64(characters)
+}
+
+// commit releases the reservation of a request and adds the characters billed for it by voice `v` to the store.
+// A failing store does not fail the request, which has been billed already.
+func (m *UsageMeter) commit(reserved int, v voice, billed int) {
+	now := m.now().UTC()
+	// the store is written without holding the lock, so that a slow store does not hold up the budget checks. It is
+	// written before the reservation is released, so that the characters are never missing from both.
+	r := UsageRecord{
+		Day:        now.Format(usageDayLayout),
+		Voice:      v.name,
+		VoiceType:  v.billingType(),
+		Characters: int64(billed),
+		Requests:   1,
+	}
+	if err := m.store.Add(r); err != nil {
+		log.Printf("failed to record usage, %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending -= int64(reserved)
+	if now.Format(usageMonthLayout) == m.month {
+		m.used += int64(billed)
+	}
+}
+
+// billingType returns the UsageRecord.VoiceType of the voice.
+func (v voice) billingType() string {
+	if v.custom {
+		return VoiceTypeCustomNeural
+	}
+	if strings.HasSuffix(v.name, "Neural") {
+		return voiceNeural.String()
+	}
+	return voiceStandard.String()
+}
+
+type usageKey struct {
+	day, voice, voiceType string
+}
+
+// usageTotals aggregates UsageRecords by day, voice and voice type.
+type usageTotals map[usageKey]*UsageRecord
+
+func (t usageTotals) add(r UsageRecord) {
+	k := usageKey{day: r.Day, voice: r.Voice, voiceType: r.VoiceType}
+	if e, ok := t[k]; ok {
+		e.Characters += r.Characters
+		e.Requests += r.Requests
+		return
+	}
+	t[k] = &r
+}
+
+func (t usageTotals) records() []UsageRecord {
+	records := make([]UsageRecord, 0, len(t))
+	for _, r := range t {
+		records = append(records, *r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Day != records[j].Day {
+			return records[i].Day < records[j].Day
+		}
+		if records[i].Voice != records[j].Voice {
+			return records[i].Voice < records[j].Voice
+		}
+		return records[i].VoiceType < records[j].VoiceType
+	})
+	return records
+}
+
+// MemoryUsageStore is an in-memory UsageStore, its totals are lost when the process exits.
+type MemoryUsageStore struct {
+	mu     sync.Mutex
+	totals usageTotals
+}
+
+// NewMemoryUsageStore returns an empty MemoryUsageStore.
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{totals: usageTotals{}}
+}
+
+// Add implements UsageStore.
+func (s *MemoryUsageStore) Add(r UsageRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totals.add(r)
+	return nil
+}
+
+// Records implements UsageStore.
+func (s *MemoryUsageStore) Records() ([]UsageRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totals.records(), nil
+}
+
+// FileUsageStore is a UsageStore persisting its totals as a JSON array within a file, which is replaced atomically on
+// every change so that the totals survive restarts and crashes. As every billed request rewrites the file, the store
+// suits low request rates; busy services should implement a UsageStore backed by a database instead.
+type FileUsageStore struct {
+	path string
+
+	mu     sync.Mutex
+	totals usageTotals
+}
+
+// OpenFileUsageStore opens the usage file at `path`, which is created by the first Add when missing.
+func OpenFileUsageStore(path string) (*FileUsageStore, error) {
+	s := &FileUsageStore{path: path, totals: usageTotals{}}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read usage file, %v", err)
+	}
+	var records []UsageRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, fmt.Errorf("unable to read usage file, %v", err)
+	}
+	for _, r := range records {
+		s.totals.add(r)
+	}
+	return s, nil
+}
+
+// Add implements UsageStore.
+func (s *FileUsageStore) Add(r UsageRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totals.add(r)
+	if err := s.write(s.totals.records()); err != nil {
+		// keep the totals in line with the file.
+		s.totals.add(UsageRecord{Day: r.Day, Voice: r.Voice, VoiceType: r.VoiceType, Characters: -r.Characters, Requests: -r.Requests})
+		return fmt.Errorf("unable to write usage file, %v", err)
+	}
+	return nil
+}
+
+// Records implements UsageStore.
+func (s *FileUsageStore) Records() ([]UsageRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totals.records(), nil
+}
+
+// write replaces the usage file with `records`.
+func (s *FileUsageStore) write(records []UsageRecord) error {
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
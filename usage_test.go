@@ -0,0 +1,165 @@
+package azuretexttospeech
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageMeter(t *testing.T) {
+	audio := riffFixture(wav.FormatMuLaw, 8000, 8, 4096)
+	var status, requests int32
+	ts := regionServer(audio, &status, &requests)
+	defer ts.Close()
+
+	az := regionClient(ts.URL)
+	az.customVoiceURL = ts.URL
+	assert.NoError(t, az.RegisterCustomVoice(CustomVoice{Name: "ContosoNeural", Locale: LocaleEnUS, DeploymentID: "SYS64738"}))
+	az.Cache = NewMemoryCache(0, 0)
+	az.Usage = NewUsageMeter(UsageOptions{MonthlyBudget: 20})
+	now := time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC)
+	az.Usage.now = func() time.Time { return now }
+	synthesize := func(text string) error {
+		_, err := az.SynthesizeWithContext(context.Background(), text, LocaleEnUS, GenderFemale, AudioRIFF8Bit8kHzMonoPCM)
+		return err
+	}
+
+	assert.NoError(t, synthesize("hello"))
+	assert.NoError(t, synthesize("hello"), "served from the cache, nothing is billed")
+	_, err := az.SynthesizeVoiceWithContext(context.Background(), "custom", "ContosoNeural", AudioRIFF8Bit8kHzMonoPCM)
+	assert.NoError(t, err)
+
+	// failed requests are not billed.
+	atomic.StoreInt32(&status, http.StatusInternalServerError)
+	assert.Error(t, synthesize("broken"))
+	atomic.StoreInt32(&status, 0)
+
+	records, err := az.Usage.Records()
+	assert.NoError(t, err)
+	assert.Equal(t, []UsageRecord{
+		{Day: "2024-05-31", Voice: "ContosoNeural", VoiceType: VoiceTypeCustomNeural, Characters: 6, Requests: 1},
+		{Day: "2024-05-31", Voice: "en-US-JennyNeural", VoiceType: "Neural", Characters: 5, Requests: 1},
+	}, records)
+
+	// requests beyond the budget are refused without contacting Azure.
+	atomic.StoreInt32(&requests, 0)
+	err = synthesize("beyond the budget")
+	var qe *QuotaExceededError
+	assert.True(t, errors.As(err, &qe))
+	assert.Equal(t, &QuotaExceededError{Month: "2024-05", Used: 11, Requested: 17, Budget: 20}, qe)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+	assert.NoError(t, synthesize("fits"))
+
+	// the budget is reset every month.
+	now = now.Add(2 * time.Hour)
+	assert.NoError(t, synthesize("beyond the budget"))
+	used, err := az.Usage.Month(now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(17), used)
+	used, err = az.Usage.Month(now.AddDate(0, -1, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), used)
+}
+
+func TestUsageMeterPending(t *testing.T) {
+	m := NewUsageMeter(UsageOptions{MonthlyBudget: 10})
+	assert.NoError(t, m.reserve(6))
+	err := m.reserve(6)
+	assert.Error(t, err, "requests in flight count against the budget")
+	assert.Contains(t, err.Error(), "6 used and 6 requested of 10")
+	m.cancel(6)
+	assert.NoError(t, m.reserve(6))
+}
+
+// countingUsageStore counts the reads of the records of a UsageStore, failing them with `err` when set.
+type countingUsageStore struct {
+	UsageStore
+	reads int
+	err   error
+}
+
+func (s *countingUsageStore) Records() ([]UsageRecord, error) {
+	s.reads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.UsageStore.Records()
+}
+
+func TestUsageMeterRunningTotal(t *testing.T) {
+	store := &countingUsageStore{UsageStore: NewMemoryUsageStore()}
+	assert.NoError(t, store.Add(UsageRecord{Day: "2024-05-01", Voice: "en-US-JennyNeural", VoiceType: "Neural", Characters: 5, Requests: 1}))
+	m := NewUsageMeter(UsageOptions{Store: store, MonthlyBudget: 10})
+	now := time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	v := voice{name: "en-US-JennyNeural"}
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, m.reserve(2))
+		m.commit(2, v, 2)
+	}
+	assert.Error(t, m.reserve(2))
+	assert.Equal(t, 1, store.reads, "the month is loaded once")
+
+	now = now.Add(2 * time.Hour)
+	assert.NoError(t, m.reserve(10))
+	assert.Equal(t, 2, store.reads, "a new month is loaded again")
+}
+
+func TestUsageMeterStoreError(t *testing.T) {
+	store := &countingUsageStore{UsageStore: NewMemoryUsageStore(), err: errors.New("store unavailable")}
+	m := NewUsageMeter(UsageOptions{Store: store, MonthlyBudget: 10})
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	v := voice{name: "en-US-JennyNeural"}
+
+	// requests are let through while the budget cannot be checked.
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, m.reserve(8))
+		m.commit(8, v, 8)
+	}
+	assert.Equal(t, 1, store.reads, "the store is not read again before the retry interval")
+
+	// the budget is checked again once the store recovers.
+	store.err = nil
+	now = now.Add(usageRetryInterval)
+	err := m.reserve(1)
+	var qe *QuotaExceededError
+	assert.True(t, errors.As(err, &qe))
+	assert.Equal(t, int64(16), qe.Used)
+	assert.Equal(t, 2, store.reads)
+}
+
+func TestFileUsageStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "azuretts-usage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "usage.json")
+	s, err := OpenFileUsageStore(path)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Add(UsageRecord{Day: "2024-06-02", Voice: "en-US-JennyNeural", VoiceType: "Neural", Characters: 5, Requests: 1}))
+	assert.NoError(t, s.Add(UsageRecord{Day: "2024-06-01", Voice: "en-US-JennyNeural", VoiceType: "Neural", Characters: 3, Requests: 1}))
+	assert.NoError(t, s.Add(UsageRecord{Day: "2024-06-02", Voice: "en-US-JennyNeural", VoiceType: "Neural", Characters: 7, Requests: 1}))
+
+	// the totals survive reopening the store.
+	s, err = OpenFileUsageStore(path)
+	assert.NoError(t, err)
+	records, err := s.Records()
+	assert.NoError(t, err)
+	assert.Equal(t, []UsageRecord{
+		{Day: "2024-06-01", Voice: "en-US-JennyNeural", VoiceType: "Neural", Characters: 3, Requests: 1},
+		{Day: "2024-06-02", Voice: "en-US-JennyNeural", VoiceType: "Neural", Characters: 12, Requests: 2},
+	}, records)
+
+	_, err = OpenFileUsageStore(filepath.Join(dir, "missing.json"))
+	assert.NoError(t, err, "the file is created by the first record")
+}
@@ -0,0 +1,138 @@
+package azuretexttospeech
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/jesseward/azuretexttospeech/mp3"
+	"github.com/jesseward/azuretexttospeech/wav"
+)
+
+// AudioFormatError is returned when a successful response does not contain audio in the requested AudioOutput, such as
+// an HTML error page returned by a proxy with a 200 status code.
+type AudioFormatError struct {
+	AudioOutput AudioOutput // format that was requested.
+	ContentType string      // Content-Type header of the response.
+	Reason      string
+}
+
+func (e *AudioFormatError) Error() string {
+	return fmt.Sprintf("response does not contain %s audio (Content-Type=%q), %s", e.AudioOutput, e.ContentType, e.Reason)
+}
+
+// validateAudio verifies `b` against the layout of `audioOutput`, returning an *AudioFormatError on mismatch.
+func validateAudio(b []byte, contentType string, audioOutput AudioOutput) error {
+	fail := func(format string, args ...interface{}) error {
+		return &AudioFormatError{AudioOutput: audioOutput, ContentType: contentType, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return fail("unable to parse Content-Type, %v", err)
+		}
+		if !isAudioMediaType(mediaType) {
+			return fail("unexpected media type %s", mediaType)
+		}
+	}
+	if len(b) == 0 {
+		return fail("response body is empty")
+	}
+
+	f := audioOutput.Format()
+	switch f.Container {
+	case ContainerRIFF:
+		return validateRIFF(b, f, fail)
+	case ContainerMP3:
+		if !hasMP3FrameSync(b) {
+			return fail("missing MPEG frame sync")
+		}
+	case ContainerOgg:
+		if !bytes.HasPrefix(b, []byte("OggS")) {
+			return fail("missing OggS capture pattern")
+		}
+		if f.Codec == CodecOpus && !bytes.Contains(b[:min(len(b), 512)], []byte("OpusHead")) {
+			return fail("missing OpusHead identification header")
+		}
+	case ContainerWebM:
+		if !bytes.HasPrefix(b, []byte{0x1a, 0x45, 0xdf, 0xa3}) {
+			return fail("missing EBML header")
+		}
+	case ContainerAMR:
+		if !bytes.HasPrefix(b, []byte("#!AMR-WB\n")) {
+			return fail("missing #!AMR-WB magic")
+		}
+	case ContainerRaw:
+		// headerless samples carry no signature, catch the common case of a markup or JSON document instead.
+		if looksLikeText(b) {
+			return fail("body is a text document")
+		}
+		if f.BitDepth > 8 && len(b)%(f.BitDepth/8) != 0 {
+			return fail("length %d is not a multiple of the %d bit sample size", len(b), f.BitDepth)
+		}
+	}
+	return nil
+}
+
+// isAudioMediaType returns false for media types that can not carry audio, such as text/html or application/json.
+func isAudioMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "audio/"), strings.HasPrefix(mediaType, "video/"):
+		return true
+	case mediaType == "application/octet-stream", mediaType == "application/ogg":
+		return true
+	}
+	return false
+}
+
+// validateRIFF verifies the RIFF/WAVE header and fmt chunk of `b` against `f`.
+func validateRIFF(b []byte, f AudioFormat, fail func(string, ...interface{}) error) error {
+	w, err := wav.Decode(b)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	if want, ok := wavFormat(f); ok && w.Format.Tag != want.Tag {
+		return fail("fmt chunk format tag is 0x%04x, expected 0x%04x", w.Format.Tag, want.Tag)
+	}
+	if w.Format.Channels != f.Channels || w.Format.SampleRate != f.SampleRate {
+		return fail("fmt chunk describes %d channel(s) at %dHz", w.Format.Channels, w.Format.SampleRate)
+	}
+	if f.BitDepth > 0 && w.Format.BitsPerSample != f.BitDepth {
+		return fail("fmt chunk describes %d bit samples", w.Format.BitsPerSample)
+	}
+	return nil
+}
+
+// hasMP3FrameSync returns true if `b` begins with an MPEG audio frame, optionally preceded by an ID3v2 tag.
+func hasMP3FrameSync(b []byte) bool {
+	n := mp3.ID3v2Size(b)
+	if n > len(b) {
+		return false
+	}
+	_, err := mp3.ParseHeader(b[n:])
+	return err == nil
+}
+
+// looksLikeText returns true when `b` starts with a markup or JSON document.
+func looksLikeText(b []byte) bool {
+	head := bytes.TrimLeft(b[:min(len(b), 512)], " \t\r\n")
+	if len(head) == 0 || (head[0] != '<' && head[0] != '{' && head[0] != '[') {
+		return false
+	}
+	for _, c := range head {
+		if c < 0x20 && c != '\t' && c != '\r' && c != '\n' || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
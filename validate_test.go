@@ -0,0 +1,78 @@
+package azuretexttospeech
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jesseward/azuretexttospeech/wav"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAudio(t *testing.T) {
+	mp3Frame := []byte{0xff, 0xf3, 0x44, 0xc4, 0x00}
+	id3 := append([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 2, 0, 0}, mp3Frame...)
+	ogg := append([]byte("OggS\x00\x02"), make([]byte, 22)...)
+	ogg = append(ogg, []byte("OpusHead")...)
+
+	tests := []struct {
+		name        string
+		audio       []byte
+		contentType string
+		audioOutput AudioOutput
+		valid       bool
+	}{
+		{"riff pcm", riffFixture(wav.FormatPCM, 24000, 16, 16), "audio/x-wav", AudioRIFF24khz16bitMonoPcm, true},
+		{"riff mulaw", riffFixture(wav.FormatMuLaw, 8000, 8, 16), "", AudioRIFF8Bit8kHzMonoPCM, true},
+		{"riff wrong rate", riffFixture(wav.FormatPCM, 16000, 16, 16), "", AudioRIFF24khz16bitMonoPcm, false},
+		{"riff wrong codec", riffFixture(wav.FormatPCM, 8000, 8, 16), "", AudioRIFF8khz8bitMonoALaw, false},
+		{"riff html", []byte("<html><body>Gateway</body></html>"), "", AudioRIFF24khz16bitMonoPcm, false},
+		{"mp3", mp3Frame, "audio/mpeg", Audio16khz32kbitrateMonoMp3, true},
+		{"mp3 with id3", id3, "audio/mpeg", Audio16khz32kbitrateMonoMp3, true},
+		{"mp3 json", []byte(`{"error":"denied"}`), "audio/mpeg", Audio16khz32kbitrateMonoMp3, false},
+		{"ogg", ogg, "audio/ogg", AudioOgg24khz16bitMonoOpus, true},
+		{"ogg missing opus", []byte("OggS\x00\x02"), "audio/ogg", AudioOgg24khz16bitMonoOpus, false},
+		{"webm", []byte{0x1a, 0x45, 0xdf, 0xa3, 0x01}, "audio/webm", AudioWebm24khz16bitMonoOpus, true},
+		{"raw pcm", []byte{0x01, 0x00, 0xff, 0xff}, "", AudioRAW24khz16bitMonoPcm, true},
+		{"raw pcm odd length", []byte{0x01, 0x00, 0xff}, "", AudioRAW24khz16bitMonoPcm, false},
+		{"raw pcm html", []byte("<!DOCTYPE html>\n<html></html>"), "", AudioRAW24khz16bitMonoPcm, false},
+		{"html content type", mp3Frame, "text/html; charset=utf-8", Audio16khz32kbitrateMonoMp3, false},
+		{"json content type", mp3Frame, "application/json", Audio16khz32kbitrateMonoMp3, false},
+		{"empty", []byte{}, "audio/mpeg", Audio16khz32kbitrateMonoMp3, false},
+	}
+
+	for _, tc := range tests {
+		err := validateAudio(tc.audio, tc.contentType, tc.audioOutput)
+		if tc.valid {
+			assert.NoError(t, err, tc.name)
+			continue
+		}
+		var formatErr *AudioFormatError
+		assert.True(t, errors.As(err, &formatErr), tc.name)
+	}
+}
+
+func TestSynthesizeValidatesAudio(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Please sign in</body></html>"))
+		}),
+	)
+	defer ts.Close()
+
+	az := &AzureCSTextToSpeech{
+		textToSpeechURL: ts.URL,
+		RegionVoiceMap: map[supportedVoices]string{
+			{GenderMale, LocaleDeCH}: "SYS2064",
+		},
+	}
+	payload, err := az.Synthesize("SYS4096", LocaleDeCH, GenderMale, Audio16khz32kbitrateMonoMp3)
+	assert.Nil(t, payload)
+
+	var formatErr *AudioFormatError
+	assert.True(t, errors.As(err, &formatErr))
+	assert.Equal(t, Audio16khz32kbitrateMonoMp3, formatErr.AudioOutput)
+	assert.Equal(t, "text/html", formatErr.ContentType)
+}
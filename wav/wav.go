@@ -0,0 +1,401 @@
+/*
+Package wav reads and writes RIFF/WAVE files, such as those returned for the `riff-*` audio outputs of the Azure
+text-to-speech API. It parses the fmt, data, fact and LIST/INFO chunks, preserves unknown chunks, and converts between
+RIFF files and the headerless `raw-*` variants.
+*/
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jesseward/azuretexttospeech/g711"
+)
+
+// Format tags found within the fmt chunk.
+const (
+	FormatPCM        uint16 = 0x0001
+	FormatALaw       uint16 = 0x0006
+	FormatMuLaw      uint16 = 0x0007
+	FormatExtensible uint16 = 0xfffe
+)
+
+// ErrNotWAVE is returned when the input does not start with a RIFF/WAVE header.
+var ErrNotWAVE = errors.New("wav: missing RIFF/WAVE header")
+
+// Format describes the layout of the samples within the data chunk.
+type Format struct {
+	Tag           uint16 // FormatPCM, FormatALaw, FormatMuLaw, ...
+	Channels      int
+	SampleRate    int
+	BitsPerSample int
+}
+
+// BlockAlign returns the size in bytes of one frame, one sample for each channel.
+func (f Format) BlockAlign() int {
+	return f.Channels * ((f.BitsPerSample + 7) / 8)
+}
+
+// ByteRate returns the number of bytes per second of audio.
+func (f Format) ByteRate() int {
+	return f.SampleRate * f.BlockAlign()
+}
+
+// Duration returns the playback duration of `n` bytes of audio in this format.
+func (f Format) Duration(n int) time.Duration {
+	if f.ByteRate() == 0 {
+		return 0
+	}
+	return time.Duration(int64(n) * int64(time.Second) / int64(f.ByteRate()))
+}
+
+// Chunk is a RIFF chunk that is not interpreted by this package.
+type Chunk struct {
+	ID   string
+	Data []byte
+}
+
+// File is a decoded RIFF/WAVE file.
+type File struct {
+	Format Format
+	Data   []byte            // contents of the data chunk.
+	Info   map[string]string // LIST/INFO entries keyed by their four character ID, e.g. "INAM" or "IART".
+	Chunks []Chunk           // remaining chunks, in the order they were found.
+}
+
+// New returns a File holding the raw audio `data` in format `f`.
+func New(f Format, data []byte) *File {
+	return &File{Format: f, Data: data}
+}
+
+// Decode parses the RIFF/WAVE file `b`. Chunk sizes exceeding the input, as written by streaming encoders that do not
+// know the final length up front, are capped to the bytes available.
+func Decode(b []byte) (*File, error) {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, ErrNotWAVE
+	}
+
+	f := &File{}
+	var haveFmt, haveData bool
+	for i := 12; i+8 <= len(b); {
+		id := string(b[i : i+4])
+		size := int64(binary.LittleEndian.Uint32(b[i+4 : i+8]))
+		start := i + 8
+		if avail := int64(len(b) - start); size > avail {
+			size = avail
+		}
+		body := b[start : start+int(size)]
+
+		switch id {
+		case "fmt ":
+			if err := f.decodeFormat(body); err != nil {
+				return nil, err
+			}
+			haveFmt = true
+		case "data":
+			f.Data = body
+			haveData = true
+		case "fact":
+			// the sample count is derived from the data chunk, the fact chunk is rewritten by Bytes.
+		case "LIST":
+			if len(body) >= 4 && string(body[0:4]) == "INFO" {
+				f.Info = decodeInfo(body[4:])
+				break
+			}
+			f.Chunks = append(f.Chunks, Chunk{ID: id, Data: body})
+		default:
+			f.Chunks = append(f.Chunks, Chunk{ID: id, Data: body})
+		}
+		i = start + int(size) + int(size%2)
+	}
+
+	if !haveFmt {
+		return nil, fmt.Errorf("wav: missing fmt chunk")
+	}
+	if !haveData {
+		return nil, fmt.Errorf("wav: missing data chunk")
+	}
+	return f, nil
+}
+
+func (f *File) decodeFormat(b []byte) error {
+	if len(b) < 16 {
+		return fmt.Errorf("wav: fmt chunk is %d bytes, expected at least 16", len(b))
+	}
+	f.Format = Format{
+		Tag:           binary.LittleEndian.Uint16(b[0:2]),
+		Channels:      int(binary.LittleEndian.Uint16(b[2:4])),
+		SampleRate:    int(binary.LittleEndian.Uint32(b[4:8])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(b[14:16])),
+	}
+	// WAVE_FORMAT_EXTENSIBLE carries the actual format tag within the first two bytes of the sub-format GUID.
+	if f.Format.Tag == FormatExtensible && len(b) >= 26 {
+		f.Format.Tag = binary.LittleEndian.Uint16(b[24:26])
+	}
+	if f.Format.Channels == 0 || f.Format.BitsPerSample == 0 || f.Format.SampleRate == 0 {
+		return fmt.Errorf("wav: fmt chunk describes %d channel(s) of %d bit samples at %dHz", f.Format.Channels, f.Format.BitsPerSample, f.Format.SampleRate)
+	}
+	return nil
+}
+
+func decodeInfo(b []byte) map[string]string {
+	info := make(map[string]string)
+	for i := 0; i+8 <= len(b); {
+		id := string(b[i : i+4])
+		size := int(binary.LittleEndian.Uint32(b[i+4 : i+8]))
+		if size > len(b)-i-8 {
+			size = len(b) - i - 8
+		}
+		info[id] = string(bytes.TrimRight(b[i+8:i+8+size], "\x00"))
+		i += 8 + size + size%2
+	}
+	return info
+}
+
+// Bytes encodes the File as a RIFF/WAVE file. Non-PCM formats are written with the fact chunk required by the
+// specification.
+func (f *File) Bytes() []byte {
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+
+	fmtChunk := make([]byte, 16, 18)
+	binary.LittleEndian.PutUint16(fmtChunk[0:2], f.Format.Tag)
+	binary.LittleEndian.PutUint16(fmtChunk[2:4], uint16(f.Format.Channels))
+	binary.LittleEndian.PutUint32(fmtChunk[4:8], uint32(f.Format.SampleRate))
+	binary.LittleEndian.PutUint32(fmtChunk[8:12], uint32(f.Format.ByteRate()))
+	binary.LittleEndian.PutUint16(fmtChunk[12:14], uint16(f.Format.BlockAlign()))
+	binary.LittleEndian.PutUint16(fmtChunk[14:16], uint16(f.Format.BitsPerSample))
+	if f.Format.Tag != FormatPCM {
+		fmtChunk = append(fmtChunk, 0, 0) // cbSize
+	}
+	writeChunk(&body, "fmt ", fmtChunk)
+
+	if f.Format.Tag != FormatPCM {
+		fact := make([]byte, 4)
+		binary.LittleEndian.PutUint32(fact, uint32(f.NumFrames()))
+		writeChunk(&body, "fact", fact)
+	}
+
+	if len(f.Info) > 0 {
+		writeChunk(&body, "LIST", encodeInfo(f.Info))
+	}
+	for _, c := range f.Chunks {
+		writeChunk(&body, c.ID, c.Data)
+	}
+	writeChunk(&body, "data", f.Data)
+
+	out := make([]byte, 8, 8+body.Len())
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:8], uint32(body.Len()))
+	return append(out, body.Bytes()...)
+}
+
+func encodeInfo(info map[string]string) []byte {
+	ids := make([]string, 0, len(info))
+	for id := range info {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var b bytes.Buffer
+	b.WriteString("INFO")
+	for _, id := range ids {
+		writeChunk(&b, id, append([]byte(info[id]), 0))
+	}
+	return b.Bytes()
+}
+
+func writeChunk(w *bytes.Buffer, id string, data []byte) {
+	var header [8]byte
+	copy(header[0:4], id)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(data)))
+	w.Write(header[:])
+	w.Write(data)
+	if len(data)%2 == 1 {
+		w.WriteByte(0)
+	}
+}
+
+// Encode returns a RIFF/WAVE file holding the raw audio `data` in format `f`.
+func Encode(f Format, data []byte) []byte {
+	return New(f, data).Bytes()
+}
+
+// NumFrames returns the number of sample frames within the data chunk.
+func (f *File) NumFrames() int {
+	if f.Format.BlockAlign() == 0 {
+		return 0
+	}
+	return len(f.Data) / f.Format.BlockAlign()
+}
+
+// Duration returns the playback duration of the data chunk.
+func (f *File) Duration() time.Duration {
+	return f.Format.Duration(len(f.Data))
+}
+
+// Slice returns a File holding the audio between `start` and `end`, rounded down to frame boundaries. A zero or
+// out of range `end` selects the remainder of the audio.
+func (f *File) Slice(start, end time.Duration) *File {
+	align := f.Format.BlockAlign()
+	offset := func(d time.Duration) int {
+		n := int(int64(d) * int64(f.Format.SampleRate) / int64(time.Second))
+		if n < 0 {
+			n = 0
+		}
+		if n*align > len(f.Data) {
+			return len(f.Data) / align * align
+		}
+		return n * align
+	}
+
+	from, to := offset(start), len(f.Data)/align*align
+	if end > 0 {
+		to = offset(end)
+	}
+	if from > to {
+		from = to
+	}
+	out := *f
+	out.Data = f.Data[from:to]
+	return &out
+}
+
+// Join concatenates the audio of `files`, which must share the same Format. Info and chunks are taken from the first file.
+func Join(files ...*File) (*File, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("wav: nothing to join")
+	}
+	out := *files[0]
+	var data []byte
+	for i, f := range files {
+		if f.Format != out.Format {
+			return nil, fmt.Errorf("wav: file %d has format %+v, expected %+v", i, f.Format, out.Format)
+		}
+		data = append(data, f.Data...)
+	}
+	out.Data = data
+	return &out, nil
+}
+
+// ToRaw strips the RIFF header from `b`, returning the contents of the data chunk and its Format.
+func ToRaw(b []byte) ([]byte, Format, error) {
+	f, err := Decode(b)
+	if err != nil {
+		return nil, Format{}, err
+	}
+	return f.Data, f.Format, nil
+}
+
+// Samples returns the interleaved samples of a PCM, mu-law or A-law file scaled to the range [-1, 1).
+func (f *File) Samples() ([]float64, error) {
+	switch f.Format.Tag {
+	case FormatMuLaw, FormatALaw:
+		return f.companded(), nil
+	case FormatPCM:
+	default:
+		return nil, fmt.Errorf("wav: unable to decode samples of format 0x%04x", f.Format.Tag)
+	}
+	bps := (f.Format.BitsPerSample + 7) / 8
+	if bps < 1 || bps > 4 {
+		return nil, fmt.Errorf("wav: unsupported sample size of %d bits", f.Format.BitsPerSample)
+	}
+
+	n := len(f.Data) / bps
+	samples := make([]float64, n)
+	for i := 0; i < n; i++ {
+		b := f.Data[i*bps : i*bps+bps]
+		switch bps {
+		case 1:
+			// 8 bit PCM is unsigned.
+			samples[i] = (float64(b[0]) - 128) / 128
+		case 2:
+			samples[i] = float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+		case 3:
+			v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+			samples[i] = float64(v) / (1 << 23)
+		case 4:
+			samples[i] = float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+		}
+	}
+	return samples, nil
+}
+
+// SetSamples replaces the data chunk of a PCM, mu-law or A-law file with the interleaved `samples`, clipping values
+// outside of [-1, 1).
+func (f *File) SetSamples(samples []float64) error {
+	switch f.Format.Tag {
+	case FormatMuLaw, FormatALaw:
+		f.setCompanded(samples)
+		return nil
+	case FormatPCM:
+	default:
+		return fmt.Errorf("wav: unable to encode samples of format 0x%04x", f.Format.Tag)
+	}
+	bps := (f.Format.BitsPerSample + 7) / 8
+	if bps < 1 || bps > 4 {
+		return fmt.Errorf("wav: unsupported sample size of %d bits", f.Format.BitsPerSample)
+	}
+
+	data := make([]byte, len(samples)*bps)
+	for i, s := range samples {
+		b := data[i*bps : i*bps+bps]
+		switch bps {
+		case 1:
+			b[0] = uint8(quantize(s, 1<<7) + 128)
+		case 2:
+			binary.LittleEndian.PutUint16(b, uint16(int16(quantize(s, 1<<15))))
+		case 3:
+			v := int32(quantize(s, 1<<23))
+			b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+		case 4:
+			binary.LittleEndian.PutUint32(b, uint32(int32(quantize(s, 1<<31))))
+		}
+	}
+	f.Data = data
+	return nil
+}
+
+// companded decodes the G.711 data chunk.
+func (f *File) companded() []float64 {
+	samples := make([]float64, len(f.Data))
+	for i, b := range f.Data {
+		if f.Format.Tag == FormatMuLaw {
+			samples[i] = float64(g711.DecodeMuLaw(b)) / (1 << 15)
+		} else {
+			samples[i] = float64(g711.DecodeALaw(b)) / (1 << 15)
+		}
+	}
+	return samples
+}
+
+// setCompanded encodes `samples` into a G.711 data chunk.
+func (f *File) setCompanded(samples []float64) {
+	data := make([]byte, len(samples))
+	for i, s := range samples {
+		v := int16(quantize(s, 1<<15))
+		if f.Format.Tag == FormatMuLaw {
+			data[i] = g711.EncodeMuLaw(v)
+		} else {
+			data[i] = g711.EncodeALaw(v)
+		}
+	}
+	f.Data = data
+}
+
+// quantize scales `s` by `full` and rounds to the nearest integer within the range of a signed sample.
+func quantize(s, full float64) int64 {
+	v := math.Round(s * full)
+	if v > full-1 {
+		v = full - 1
+	}
+	if v < -full {
+		v = -full
+	}
+	return int64(v)
+}
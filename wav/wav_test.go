@@ -0,0 +1,116 @@
+package wav
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var pcm16 = Format{Tag: FormatPCM, Channels: 1, SampleRate: 16000, BitsPerSample: 16}
+
+func TestEncodeDecode(t *testing.T) {
+	data := []byte{0x01, 0x00, 0xff, 0x7f, 0x00, 0x80}
+	b := Encode(pcm16, data)
+
+	assert.Equal(t, "RIFF", string(b[0:4]))
+	assert.Equal(t, uint32(len(b)-8), binary.LittleEndian.Uint32(b[4:8]))
+	assert.Equal(t, 44+len(data), len(b), "PCM files have the canonical 44 byte header")
+
+	f, err := Decode(b)
+	assert.NoError(t, err)
+	assert.Equal(t, pcm16, f.Format)
+	assert.Equal(t, data, f.Data)
+	assert.Equal(t, 3, f.NumFrames())
+}
+
+func TestEncodeMuLaw(t *testing.T) {
+	mulaw := Format{Tag: FormatMuLaw, Channels: 1, SampleRate: 8000, BitsPerSample: 8}
+	b := Encode(mulaw, make([]byte, 8000))
+
+	f, err := Decode(b)
+	assert.NoError(t, err)
+	assert.Equal(t, mulaw, f.Format)
+	assert.Equal(t, time.Second, f.Duration())
+	assert.Contains(t, string(b[:64]), "fact", "non-PCM files require a fact chunk")
+}
+
+func TestDecodeChunks(t *testing.T) {
+	f := New(pcm16, []byte{0, 0, 0})
+	f.Info = map[string]string{"INAM": "Greeting", "IART": "en-US-JennyNeural"}
+	f.Chunks = []Chunk{{ID: "cue ", Data: []byte{1, 2, 3}}}
+
+	d, err := Decode(f.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, f.Info, d.Info)
+	assert.Equal(t, f.Chunks, d.Chunks)
+	assert.Equal(t, []byte{0, 0, 0}, d.Data, "odd sized chunks are padded")
+}
+
+func TestDecodeStreamingSizes(t *testing.T) {
+	b := Encode(pcm16, make([]byte, 100))
+	// streaming encoders write placeholder sizes.
+	binary.LittleEndian.PutUint32(b[4:8], 0xffffffff)
+	binary.LittleEndian.PutUint32(b[40:44], 0xffffffff)
+
+	f, err := Decode(b)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, len(f.Data))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := Decode([]byte("<html></html>"))
+	assert.Equal(t, ErrNotWAVE, err)
+
+	_, err = Decode([]byte("RIFF\x04\x00\x00\x00WAVE"))
+	assert.Error(t, err)
+
+	// a zero sample rate would divide by zero in every duration computation.
+	b := Encode(Format{Tag: FormatPCM, Channels: 1, SampleRate: 0, BitsPerSample: 16}, make([]byte, 100))
+	_, err = Decode(b)
+	assert.Error(t, err)
+}
+
+func TestSamples(t *testing.T) {
+	f := New(pcm16, nil)
+	in := []float64{0, 0.5, -0.5, -1, 0.999969482421875}
+	assert.NoError(t, f.SetSamples(append(in, 2)))
+
+	out, err := f.Samples()
+	assert.NoError(t, err)
+	assert.Equal(t, append(in, 0.999969482421875), out, "values are clipped to the sample range")
+
+	for _, bits := range []int{8, 24, 32} {
+		f := New(Format{Tag: FormatPCM, Channels: 1, SampleRate: 8000, BitsPerSample: bits}, nil)
+		assert.NoError(t, f.SetSamples([]float64{0.5, -0.5}))
+		out, err := f.Samples()
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{0.5, -0.5}, out, "bits=%d", bits)
+	}
+
+	mulaw := New(Format{Tag: FormatMuLaw, Channels: 1, SampleRate: 8000, BitsPerSample: 8}, nil)
+	assert.NoError(t, mulaw.SetSamples([]float64{0, 0.5, -0.5}))
+	assert.Equal(t, []byte{0xff, 0x8f, 0x0f}, mulaw.Data)
+	out, err = mulaw.Samples()
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.5, out[1], 0.02)
+
+	_, err = New(Format{Tag: 0x0055, Channels: 1, SampleRate: 8000, BitsPerSample: 0}, []byte{0xff}).Samples()
+	assert.Error(t, err)
+}
+
+func TestSliceJoin(t *testing.T) {
+	f := New(pcm16, make([]byte, 32000))
+	head := f.Slice(0, 250*time.Millisecond)
+	tail := f.Slice(250*time.Millisecond, 0)
+	assert.Equal(t, 250*time.Millisecond, head.Duration())
+	assert.Equal(t, 750*time.Millisecond, tail.Duration())
+
+	j, err := Join(head, tail)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, j.Duration())
+
+	_, err = Join(head, New(Format{Tag: FormatPCM, Channels: 1, SampleRate: 8000, BitsPerSample: 16}, nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package azuretexttospeech
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jesseward/azuretexttospeech/pcm"
+)
+
+// waveformBits is the resolution of the peak values of a Waveform.
+const waveformBits = 8
+
+// Waveform holds downsampled peak data for drawing audio, in the JSON layout of the BBC audiowaveform tool, as read
+// by waveform players such as peaks.js and wavesurfer.js. All channels are merged into one.
+type Waveform struct {
+	Version         int   `json:"version"`
+	Channels        int   `json:"channels"`
+	SampleRate      int   `json:"sample_rate"`
+	SamplesPerPixel int   `json:"samples_per_pixel"`
+	Bits            int   `json:"bits"`
+	Length          int   `json:"length"` // number of min and max pairs.
+	Data            []int `json:"data"`   // interleaved min and max pairs, from -128 to 127.
+}
+
+// Peaks computes the waveform of `audio`, rendered in `audioOutput`, with at most `pixels` min and max pairs. PCM,
+// mu-law and A-law outputs are supported; MP3 outputs are not, as their samples cannot be measured without decoding
+// the audio.
+func Peaks(audio []byte, audioOutput AudioOutput, pixels int) (*Waveform, error) {
+	if pixels < 1 {
+		return nil, fmt.Errorf("waveform requires at least one pixel, %d requested", pixels)
+	}
+	if audioOutput.Format().Container == ContainerMP3 {
+		return nil, fmt.Errorf("unable to compute waveform of %s, MP3 audio is not decoded", audioOutput)
+	}
+
+	samples, wf, err := decodeSamples(audio, audioOutput)
+	if err != nil {
+		return nil, err
+	}
+	frames := len(samples) / wf.Channels
+	samplesPerPixel := (frames + pixels - 1) / pixels
+	if samplesPerPixel < 1 {
+		samplesPerPixel = 1
+	}
+	lows, highs := pcm.Peaks(samples, wf.Channels, samplesPerPixel)
+
+	w := &Waveform{
+		Version:         2,
+		Channels:        1,
+		SampleRate:      wf.SampleRate,
+		SamplesPerPixel: samplesPerPixel,
+		Bits:            waveformBits,
+		Length:          len(highs),
+		Data:            make([]int, 0, 2*len(highs)),
+	}
+	full := float64(int(1) << (waveformBits - 1))
+	scale := func(s float64) int {
+		return int(math.Max(-full, math.Min(full-1, math.Round(s*full))))
+	}
+	for i := range highs {
+		w.Data = append(w.Data, scale(lows[i]), scale(highs[i]))
+	}
+	return w, nil
+}
+
+// SVG renders the waveform as an SVG image of `width` by `height` pixels, drawing a vertical line per min and max
+// pair. The stroke uses `currentColor`, so the colour follows the CSS of the embedding page.
+func (w *Waveform) SVG(width, height int) string {
+	var path strings.Builder
+	full := float64(int(1) << (w.Bits - 1))
+	mid := float64(height) / 2
+	for i := 0; i < w.Length; i++ {
+		x := (float64(i) + 0.5) * float64(width) / float64(w.Length)
+		top := mid - float64(w.Data[2*i+1])/full*mid
+		bottom := mid - float64(w.Data[2*i])/full*mid
+		if bottom-top < 1 {
+			// keep silence visible as a hairline.
+			top, bottom = mid-0.5, mid+0.5
+		}
+		fmt.Fprintf(&path, "M%.2f %.2fV%.2f", x, top, bottom)
+	}
+
+	stroke := float64(width) / float64(w.Length)
+	if w.Length == 0 {
+		stroke = 1
+	}
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+
+		`<path d="%s" stroke="currentColor" stroke-width="%.2f" fill="none"/></svg>`,
+		width, height, width, height, path.String(), stroke)
+}
@@ -0,0 +1,51 @@
+package azuretexttospeech
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeaks(t *testing.T) {
+	a := AudioRAW16Bit16kHzMonoMulaw
+	lead, _ := Silence(time.Second, a)
+	audio, err := JoinWithSilence(0, a, lead, toneFixture(t, a))
+	assert.NoError(t, err)
+
+	w, err := Peaks(audio, a, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, w.Length)
+	assert.Equal(t, 320, w.SamplesPerPixel)
+	assert.Equal(t, 16000, w.SampleRate)
+	assert.Equal(t, 200, len(w.Data))
+	assert.Equal(t, []int{0, 0}, w.Data[0:2], "silence")
+	assert.InDelta(t, -64, w.Data[180], 1, "a tone at half scale")
+	assert.InDelta(t, 64, w.Data[181], 1)
+
+	b, err := json.Marshal(w)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(b), `{"version":2,"channels":1,"sample_rate":16000,"samples_per_pixel":320,"bits":8,"length":100,"data":[0,0,`))
+
+	_, err = Peaks(audio, a, 0)
+	assert.Error(t, err)
+	_, err = Peaks([]byte("OggS"), AudioOgg16khz16bitMonoOpus, 10)
+	assert.Error(t, err)
+}
+
+func TestPeaksMP3(t *testing.T) {
+	// the samples of MP3 audio cannot be measured without decoding it.
+	_, err := Peaks(mp3Fixture(10), Audio16khz32kbitrateMonoMp3, 4)
+	assert.EqualError(t, err, "unable to compute waveform of audio-16khz-32kbitrate-mono-mp3, MP3 audio is not decoded")
+}
+
+func TestWaveformSVG(t *testing.T) {
+	w := &Waveform{Version: 2, Channels: 1, Bits: 8, Length: 2, Data: []int{-128, 127, 0, 0}}
+	svg := w.SVG(200, 50)
+	assert.NoError(t, xml.Unmarshal([]byte(svg), new(interface{})))
+	assert.Contains(t, svg, `width="200" height="50"`)
+	assert.Contains(t, svg, `d="M50.00 0.20V50.00M150.00 24.50V25.50"`)
+}